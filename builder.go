@@ -0,0 +1,319 @@
+package mobiledoc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// The default markups for use with the builder.
+var (
+	Bold          = Markup{Tag: "b"}
+	Italic        = Markup{Tag: "i"}
+	Strong        = Markup{Tag: "strong"}
+	Emphasis      = Markup{Tag: "em"}
+	Code          = Markup{Tag: "code"}
+	Strikethrough = Markup{Tag: "s"}
+	Underline     = Markup{Tag: "u"}
+	Subscript     = Markup{Tag: "sub"}
+	Superscript   = Markup{Tag: "sup"}
+)
+
+// Link returns a link markup for the provided URL.
+func Link(href string) Markup {
+	return Markup{
+		Tag:        "a",
+		Attributes: Map{"href": href},
+	}
+}
+
+// Builder incrementally builds a document. Markups are deduplicated and all
+// references as well as the open and closed markups of markers are managed
+// automatically.
+type Builder struct {
+	markups   []Markup
+	atoms     []Atom
+	cards     []Card
+	sections  []builderSection
+	validator *Validator
+}
+
+type builderSection struct {
+	section Section
+	card    int
+	markers []builderMarker
+	items   [][]builderMarker
+}
+
+type builderMarker struct {
+	typ     MarkerType
+	text    string
+	atom    int
+	markups []int
+}
+
+// NewBuilder creates a new Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Section will add a markup section with the provided tag.
+func (b *Builder) Section(tag string) *Builder {
+	b.sections = append(b.sections, builderSection{
+		section: Section{Type: MarkupSection, Tag: tag},
+	})
+
+	return b
+}
+
+// Paragraph will add a paragraph section.
+func (b *Builder) Paragraph() *Builder {
+	return b.Section("p")
+}
+
+// Heading will add a heading section of the provided level.
+func (b *Builder) Heading(level int) *Builder {
+	return b.Section(fmt.Sprintf("h%d", level))
+}
+
+// List will add a list section with the provided tag and items. Further items
+// can be added using Item.
+func (b *Builder) List(tag string, items ...string) *Builder {
+	// add section
+	b.sections = append(b.sections, builderSection{
+		section: Section{Type: ListSection, Tag: tag},
+	})
+
+	// add items
+	for _, item := range items {
+		b.Item().Text(item)
+	}
+
+	return b
+}
+
+// Item will add an item to the current list section. An unordered list is
+// added if the current section is not a list section.
+func (b *Builder) Item() *Builder {
+	// ensure list
+	if len(b.sections) == 0 || b.sections[len(b.sections)-1].section.Type != ListSection {
+		b.List("ul")
+	}
+
+	// add item
+	section := &b.sections[len(b.sections)-1]
+	section.items = append(section.items, nil)
+
+	return b
+}
+
+// Image will add an image section with the provided source.
+func (b *Builder) Image(source string) *Builder {
+	b.sections = append(b.sections, builderSection{
+		section: Section{Type: ImageSection, Source: source},
+	})
+
+	return b
+}
+
+// Card will add a card section with the provided name and payload.
+func (b *Builder) Card(name string, payload Map) *Builder {
+	// add card
+	b.cards = append(b.cards, Card{
+		Name:    name,
+		Payload: payload,
+	})
+
+	// add section
+	b.sections = append(b.sections, builderSection{
+		section: Section{Type: CardSection},
+		card:    len(b.cards) - 1,
+	})
+
+	return b
+}
+
+// Text will add a text marker with the provided markups to the current markup
+// section or list item. A paragraph is added if the current section does not
+// accept markers.
+func (b *Builder) Text(text string, markups ...Markup) *Builder {
+	b.add(builderMarker{
+		typ:     TextMarker,
+		text:    text,
+		markups: b.markupIndexes(markups),
+	})
+
+	return b
+}
+
+// Atom will add an atom marker with the provided markups to the current markup
+// section or list item. A paragraph is added if the current section does not
+// accept markers.
+func (b *Builder) Atom(name, text string, payload Map, markups ...Markup) *Builder {
+	// add atom
+	b.atoms = append(b.atoms, Atom{
+		Name:    name,
+		Text:    text,
+		Payload: payload,
+	})
+
+	// add marker
+	b.add(builderMarker{
+		typ:     AtomMarker,
+		atom:    len(b.atoms) - 1,
+		markups: b.markupIndexes(markups),
+	})
+
+	return b
+}
+
+// Build will build and validate the document.
+func (b *Builder) Build() (Document, error) {
+	// prepare document
+	doc := Document{
+		Version:  Version,
+		Markups:  make([]Markup, len(b.markups)),
+		Atoms:    make([]Atom, len(b.atoms)),
+		Cards:    make([]Card, len(b.cards)),
+		Sections: make([]Section, 0, len(b.sections)),
+	}
+
+	// copy definitions
+	copy(doc.Markups, b.markups)
+	copy(doc.Atoms, b.atoms)
+	copy(doc.Cards, b.cards)
+
+	// add sections
+	doc.Sections = buildSections(&doc, b.sections)
+
+	// get validator
+	validator := b.validator
+	if validator == nil {
+		validator = formatValidator
+	}
+
+	// validate document
+	err := validator.Validate(doc)
+	if err != nil {
+		return Document{}, err
+	}
+
+	return doc, nil
+}
+
+func (b *Builder) add(marker builderMarker) {
+	// ensure markup or list section
+	if len(b.sections) == 0 {
+		b.Paragraph()
+	} else if typ := b.sections[len(b.sections)-1].section.Type; typ != MarkupSection && typ != ListSection {
+		b.Paragraph()
+	}
+
+	// get section
+	section := &b.sections[len(b.sections)-1]
+
+	// add marker to markup section
+	if section.section.Type == MarkupSection {
+		section.markers = append(section.markers, marker)
+		return
+	}
+
+	// ensure item
+	if len(section.items) == 0 {
+		b.Item()
+	}
+
+	// add marker to last item
+	section.items[len(section.items)-1] = append(section.items[len(section.items)-1], marker)
+}
+
+func (b *Builder) markupIndexes(markups []Markup) []int {
+	// collect indexes
+	var list []int
+	for _, markup := range markups {
+		index := b.markupIndex(markup)
+		if !containsInt(list, index) {
+			list = append(list, index)
+		}
+	}
+
+	return list
+}
+
+func (b *Builder) markupIndex(markup Markup) int {
+	// find existing markup
+	for i, m := range b.markups {
+		if m.Tag == markup.Tag && equalAttributes(m.Attributes, markup.Attributes) {
+			return i
+		}
+	}
+
+	// add markup
+	b.markups = append(b.markups, markup)
+
+	return len(b.markups) - 1
+}
+
+func buildSections(doc *Document, sections []builderSection) []Section {
+	// build sections
+	list := make([]Section, 0, len(sections))
+	for _, bs := range sections {
+		section := bs.section
+		switch section.Type {
+		case MarkupSection:
+			section.Markers = buildMarkers(doc, bs.markers)
+		case ListSection:
+			section.Items = make([][]Marker, 0, len(bs.items))
+			for _, item := range bs.items {
+				section.Items = append(section.Items, buildMarkers(doc, item))
+			}
+		case CardSection:
+			section.Card = &doc.Cards[bs.card]
+		}
+		list = append(list, section)
+	}
+
+	return list
+}
+
+func buildMarkers(doc *Document, markers []builderMarker) []Marker {
+	// prepare lists
+	list := make([]Marker, len(markers))
+	active := make([][]*Markup, len(markers))
+
+	// convert markers
+	for i, marker := range markers {
+		list[i].Type = marker.typ
+		list[i].Text = marker.text
+		if marker.typ == AtomMarker {
+			list[i].Atom = &doc.Atoms[marker.atom]
+		}
+		for _, index := range marker.markups {
+			active[i] = append(active[i], &doc.Markups[index])
+		}
+	}
+
+	// balance markers
+	balanceMarkers(list, active)
+
+	return list
+}
+
+func equalAttributes(a, b Map) bool {
+	// empty attributes are equal regardless of allocation
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func containsInt(list []int, i int) bool {
+	// check existence
+	for _, item := range list {
+		if item == i {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package mobiledoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	doc, err := NewBuilder().
+		Heading(2).Text("Title").
+		Paragraph().
+		Text("foo ").
+		Text("bar", Bold).
+		Text("baz", Bold, Link("https://example.com")).
+		Text("qux", Link("https://example.com")).
+		Atom("mention", "@joe", Map{"id": 1}, Italic).
+		Card("card1", Map{"foo": 42}).
+		Text("implicit").
+		List("ul", "foo", "bar").Text("baz", Bold).
+		Item().Text("qux").
+		Image("https://example.com/foo.png").
+		Build()
+	assert.NoError(t, err)
+
+	m, err := Compile(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, Map{
+		"version": Version,
+		"markups": List{
+			List{"b"},
+			List{"a", List{"href", "https://example.com"}},
+			List{"i"},
+		},
+		"atoms": List{
+			List{"mention", "@joe", Map{"id": 1}},
+		},
+		"cards": List{
+			List{"card1", Map{"foo": 42}},
+		},
+		"sections": List{
+			List{MarkupSection, "h2", List{
+				List{TextMarker, List{}, 0, "Title"},
+			}},
+			List{MarkupSection, "p", List{
+				List{TextMarker, List{}, 0, "foo "},
+				List{TextMarker, List{0}, 0, "bar"},
+				List{TextMarker, List{1}, 2, "baz"},
+				List{TextMarker, List{1}, 1, "qux"},
+				List{AtomMarker, List{2}, 1, 0},
+			}},
+			List{CardSection, 0},
+			List{MarkupSection, "p", List{
+				List{TextMarker, List{}, 0, "implicit"},
+			}},
+			List{ListSection, "ul", List{
+				List{
+					List{TextMarker, List{}, 0, "foo"},
+				},
+				List{
+					List{TextMarker, List{}, 0, "bar"},
+					List{TextMarker, List{0}, 1, "baz"},
+				},
+				List{
+					List{TextMarker, List{}, 0, "qux"},
+				},
+			}},
+			List{ImageSection, "https://example.com/foo.png"},
+		},
+	}, m)
+
+	assert.NoError(t, NewFormatValidator().Validate(doc))
+}
+
+func TestBuilderEmpty(t *testing.T) {
+	doc, err := NewBuilder().Build()
+	assert.NoError(t, err)
+	assert.Equal(t, Document{
+		Version:  Version,
+		Markups:  []Markup{},
+		Atoms:    []Atom{},
+		Cards:    []Card{},
+		Sections: []Section{},
+	}, doc)
+
+	doc, err = NewBuilder().Item().Text("foo").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, Document{
+		Version: Version,
+		Markups: []Markup{},
+		Atoms:   []Atom{},
+		Cards:   []Card{},
+		Sections: []Section{
+			{Type: ListSection, Tag: "ul", Items: [][]Marker{
+				{{Type: TextMarker, Text: "foo"}},
+			}},
+		},
+	}, doc)
+}
+
+func TestBuilderNesting(t *testing.T) {
+	doc, err := NewBuilder().
+		Text("a", Bold, Italic).
+		Text("b", Italic).
+		Text("c", Italic, Bold).
+		Text("d", Bold, Bold).
+		Build()
+	assert.NoError(t, err)
+	assert.Len(t, doc.Markups, 2)
+
+	b, i := &doc.Markups[0], &doc.Markups[1]
+	assert.Equal(t, []Marker{
+		{Type: TextMarker, OpenMarkups: []*Markup{b, i}, ClosedMarkups: 2, Text: "a"},
+		{Type: TextMarker, OpenMarkups: []*Markup{i}, Text: "b"},
+		{Type: TextMarker, OpenMarkups: []*Markup{b}, ClosedMarkups: 2, Text: "c"},
+		{Type: TextMarker, OpenMarkups: []*Markup{b}, ClosedMarkups: 1, Text: "d"},
+	}, doc.Sections[0].Markers)
+}
+
+func TestBuilderInvalid(t *testing.T) {
+	_, err := NewBuilder().Heading(7).Text("foo").Build()
+	assert.Error(t, err)
+}
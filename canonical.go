@@ -0,0 +1,241 @@
+package mobiledoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// canonicalize will rebuild the document in a canonical form. Markups, atoms
+// and cards are deduplicated by value, ordered by first use and unused
+// definitions are dropped. The markups of each marker are ordered so that
+// longer running markups are opened first. If merge is set, empty text markers
+// are dropped and adjacent text markers with the same markups are merged.
+func canonicalize(doc Document, merge bool) Document {
+	// prepare canonicalizer
+	c := canonicalizer{
+		merge:   merge,
+		markups: map[string]int{},
+		atoms:   map[string]int{},
+		cards:   map[string]int{},
+	}
+
+	// prepare sections
+	sections := make([]builderSection, 0, len(doc.Sections))
+
+	// convert sections
+	for _, section := range doc.Sections {
+		bs := builderSection{section: section, card: -1}
+		bs.section.Attributes = cloneMap(section.Attributes)
+		bs.section.Markers = nil
+		bs.section.Items = nil
+		bs.section.Card = nil
+		switch section.Type {
+		case MarkupSection:
+			bs.markers = c.convertMarkers(section.Markers)
+		case ListSection:
+			bs.items = make([][]builderMarker, 0, len(section.Items))
+			for _, item := range section.Items {
+				bs.items = append(bs.items, c.convertMarkers(item))
+			}
+		case CardSection:
+			var card Card
+			if section.Card != nil {
+				card = *section.Card
+			}
+			bs.card = c.cardIndex(card)
+		}
+		sections = append(sections, bs)
+	}
+
+	// prepare result
+	result := Document{
+		Version: doc.Version,
+		Markups: c.result.Markups,
+		Atoms:   c.result.Atoms,
+		Cards:   c.result.Cards,
+	}
+
+	// ensure definitions
+	if result.Markups == nil {
+		result.Markups = []Markup{}
+	}
+	if result.Atoms == nil {
+		result.Atoms = []Atom{}
+	}
+	if result.Cards == nil {
+		result.Cards = []Card{}
+	}
+
+	// build sections
+	result.Sections = buildSections(&result, sections)
+
+	return result
+}
+
+type canonicalizer struct {
+	merge   bool
+	result  Document
+	markups map[string]int
+	atoms   map[string]int
+	cards   map[string]int
+}
+
+func (c *canonicalizer) convertMarkers(markers []Marker) []builderMarker {
+	// get active markups
+	active := activeMarkups(markers)
+
+	// prepare lists
+	list := make([]builderMarker, 0, len(markers))
+	var keys [][]string
+	var values []map[string]Markup
+
+	// convert markers
+	for i, marker := range markers {
+		// drop empty text markers
+		if c.merge && marker.Type == TextMarker && marker.Text == "" {
+			continue
+		}
+
+		// collect unique markups
+		var set []string
+		byKey := map[string]Markup{}
+		for _, markup := range active[i] {
+			if markup == nil {
+				continue
+			}
+			key := markupKey(*markup)
+			if _, ok := byKey[key]; !ok {
+				set = append(set, key)
+				byKey[key] = *markup
+			}
+		}
+
+		// merge with previous text marker
+		if n := len(list); c.merge && n > 0 && marker.Type == TextMarker && list[n-1].typ == TextMarker && sameKeys(keys[n-1], set) {
+			list[n-1].text += marker.Text
+			continue
+		}
+
+		// add marker
+		cm := builderMarker{typ: marker.Type, text: marker.Text, atom: -1}
+		if marker.Type == AtomMarker {
+			var atom Atom
+			if marker.Atom != nil {
+				atom = *marker.Atom
+			}
+			cm.atom = c.atomIndex(atom)
+		}
+		list = append(list, cm)
+		keys = append(keys, set)
+		values = append(values, byKey)
+	}
+
+	// order and register markups
+	for i := range list {
+		// compute the end of the run of each markup
+		ends := map[string]int{}
+		for _, key := range keys[i] {
+			end := i
+			for end+1 < len(list) && contains(keys[end+1], key) {
+				end++
+			}
+			ends[key] = end
+		}
+
+		// sort markups by run end and key
+		sorted := append([]string{}, keys[i]...)
+		sort.SliceStable(sorted, func(a, b int) bool {
+			if ends[sorted[a]] != ends[sorted[b]] {
+				return ends[sorted[a]] > ends[sorted[b]]
+			}
+			return sorted[a] < sorted[b]
+		})
+
+		// register markups
+		for _, key := range sorted {
+			list[i].markups = append(list[i].markups, c.markupIndex(key, values[i][key]))
+		}
+	}
+
+	return list
+}
+
+func (c *canonicalizer) markupIndex(key string, markup Markup) int {
+	// get existing markup
+	if index, ok := c.markups[key]; ok {
+		return index
+	}
+
+	// add markup
+	c.result.Markups = append(c.result.Markups, Markup{
+		Tag:        markup.Tag,
+		Attributes: cloneMap(markup.Attributes),
+	})
+	c.markups[key] = len(c.result.Markups) - 1
+
+	return len(c.result.Markups) - 1
+}
+
+func (c *canonicalizer) atomIndex(atom Atom) int {
+	// get existing atom
+	key := valueKey(atom.Name, atom.Text, atom.Payload)
+	if index, ok := c.atoms[key]; ok {
+		return index
+	}
+
+	// add atom
+	c.result.Atoms = append(c.result.Atoms, cloneAtom(atom))
+	c.atoms[key] = len(c.result.Atoms) - 1
+
+	return len(c.result.Atoms) - 1
+}
+
+func (c *canonicalizer) cardIndex(card Card) int {
+	// get existing card
+	key := valueKey(card.Name, card.Payload)
+	if index, ok := c.cards[key]; ok {
+		return index
+	}
+
+	// add card
+	c.result.Cards = append(c.result.Cards, cloneCard(card))
+	c.cards[key] = len(c.result.Cards) - 1
+
+	return len(c.result.Cards) - 1
+}
+
+func markupKey(markup Markup) string {
+	// ignore empty attributes
+	if len(markup.Attributes) == 0 {
+		return valueKey(markup.Tag)
+	}
+
+	return valueKey(markup.Tag, markup.Attributes)
+}
+
+func valueKey(values ...interface{}) string {
+	// encode values, maps are encoded with sorted keys
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Sprintf("%#v", values)
+	}
+
+	return string(buf)
+}
+
+func sameKeys(a, b []string) bool {
+	// check length
+	if len(a) != len(b) {
+		return false
+	}
+
+	// check keys
+	for _, key := range a {
+		if !contains(b, key) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,234 @@
+package mobiledoc
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Clone returns a deep copy of the document. All slices, attributes and
+// payloads are copied and the markup, atom and card references of markers and
+// sections are rewired to the copied definitions. References to definitions
+// that are not part of the document are copied as well.
+func (d Document) Clone() Document {
+	// prepare cloner
+	c := cloner{
+		markups: map[*Markup]*Markup{},
+		atoms:   map[*Atom]*Atom{},
+		cards:   map[*Card]*Card{},
+	}
+
+	// prepare document
+	doc := Document{
+		Version: d.Version,
+	}
+
+	// copy markups
+	if d.Markups != nil {
+		doc.Markups = make([]Markup, len(d.Markups))
+		for i := range d.Markups {
+			doc.Markups[i] = cloneMarkup(d.Markups[i])
+			c.markups[&d.Markups[i]] = &doc.Markups[i]
+		}
+	}
+
+	// copy atoms
+	if d.Atoms != nil {
+		doc.Atoms = make([]Atom, len(d.Atoms))
+		for i := range d.Atoms {
+			doc.Atoms[i] = cloneAtom(d.Atoms[i])
+			c.atoms[&d.Atoms[i]] = &doc.Atoms[i]
+		}
+	}
+
+	// copy cards
+	if d.Cards != nil {
+		doc.Cards = make([]Card, len(d.Cards))
+		for i := range d.Cards {
+			doc.Cards[i] = cloneCard(d.Cards[i])
+			c.cards[&d.Cards[i]] = &doc.Cards[i]
+		}
+	}
+
+	// copy sections
+	if d.Sections != nil {
+		doc.Sections = make([]Section, len(d.Sections))
+		for i, section := range d.Sections {
+			doc.Sections[i] = c.section(section)
+		}
+	}
+
+	return doc
+}
+
+type cloner struct {
+	markups map[*Markup]*Markup
+	atoms   map[*Atom]*Atom
+	cards   map[*Card]*Card
+}
+
+func (c *cloner) section(section Section) Section {
+	// copy attributes
+	section.Attributes = cloneMap(section.Attributes)
+
+	// copy markers
+	section.Markers = c.markers(section.Markers)
+
+	// copy items
+	if section.Items != nil {
+		items := make([][]Marker, len(section.Items))
+		for i, item := range section.Items {
+			items[i] = c.markers(item)
+		}
+		section.Items = items
+	}
+
+	// rewire card
+	if section.Card != nil {
+		section.Card = c.card(section.Card)
+	}
+
+	return section
+}
+
+func (c *cloner) markers(markers []Marker) []Marker {
+	// check nil
+	if markers == nil {
+		return nil
+	}
+
+	// copy markers
+	list := make([]Marker, len(markers))
+	for i, marker := range markers {
+		// rewire open markups
+		if marker.OpenMarkups != nil {
+			open := make([]*Markup, len(marker.OpenMarkups))
+			for j, markup := range marker.OpenMarkups {
+				open[j] = c.markup(markup)
+			}
+			marker.OpenMarkups = open
+		}
+
+		// rewire atom
+		if marker.Atom != nil {
+			marker.Atom = c.atom(marker.Atom)
+		}
+
+		list[i] = marker
+	}
+
+	return list
+}
+
+func (c *cloner) markup(markup *Markup) *Markup {
+	// get copy
+	if markup == nil {
+		return nil
+	} else if cp, ok := c.markups[markup]; ok {
+		return cp
+	}
+
+	// copy foreign markup
+	cp := cloneMarkup(*markup)
+	c.markups[markup] = &cp
+
+	return &cp
+}
+
+func (c *cloner) atom(atom *Atom) *Atom {
+	// get copy
+	if cp, ok := c.atoms[atom]; ok {
+		return cp
+	}
+
+	// copy foreign atom
+	cp := cloneAtom(*atom)
+	c.atoms[atom] = &cp
+
+	return &cp
+}
+
+func (c *cloner) card(card *Card) *Card {
+	// get copy
+	if cp, ok := c.cards[card]; ok {
+		return cp
+	}
+
+	// copy foreign card
+	cp := cloneCard(*card)
+	c.cards[card] = &cp
+
+	return &cp
+}
+
+func cloneMarkup(markup Markup) Markup {
+	// copy attributes
+	markup.Attributes = cloneMap(markup.Attributes)
+
+	return markup
+}
+
+func cloneAtom(atom Atom) Atom {
+	// copy payload
+	atom.Payload = cloneMap(atom.Payload)
+
+	return atom
+}
+
+func cloneCard(card Card) Card {
+	// copy payload
+	card.Payload = cloneMap(card.Payload)
+
+	return card
+}
+
+func cloneMap(m Map) Map {
+	// check nil
+	if m == nil {
+		return nil
+	}
+
+	// copy map
+	cp := make(Map, len(m))
+	for key, value := range m {
+		cp[key] = cloneValue(value)
+	}
+
+	return cp
+}
+
+func cloneValue(v interface{}) interface{} {
+	// copy maps and lists recursively
+	switch v := v.(type) {
+	case Map:
+		return cloneMap(v)
+	case bson.M:
+		return bson.M(cloneMap(v))
+	case List:
+		return cloneList(v)
+	case bson.A:
+		return bson.A(cloneList(v))
+	case bson.D:
+		if v == nil {
+			return v
+		}
+		doc := make(bson.D, len(v))
+		for i, e := range v {
+			doc[i] = bson.E{Key: e.Key, Value: cloneValue(e.Value)}
+		}
+		return doc
+	default:
+		return v
+	}
+}
+
+func cloneList(l List) List {
+	// check nil
+	if l == nil {
+		return nil
+	}
+
+	// copy list
+	cp := make(List, len(l))
+	for i, item := range l {
+		cp[i] = cloneValue(item)
+	}
+
+	return cp
+}
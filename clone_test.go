@@ -0,0 +1,62 @@
+package mobiledoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	doc := sampleDoc()
+	doc.Atoms[0].Payload["list"] = List{Map{"foo": "bar"}}
+
+	clone := doc.Clone()
+	assert.Equal(t, doc, clone)
+
+	// check rewired pointers
+	assert.True(t, clone.Sections[0].Card == &clone.Cards[0])
+	assert.True(t, clone.Sections[1].Markers[1].OpenMarkups[0] == &clone.Markups[0])
+	assert.True(t, clone.Sections[2].Markers[0].Atom == &clone.Atoms[0])
+	assert.True(t, clone.Sections[4].Items[0][1].OpenMarkups[0] == &clone.Markups[0])
+
+	// check deep copies
+	clone.Markups[2].Attributes["href"] = "https://example.org"
+	clone.Atoms[0].Payload["list"].(List)[0].(Map)["foo"] = "baz"
+	clone.Cards[0].Payload["foo"] = 7
+	clone.Sections[1].Markers[0].Text = "bar"
+	assert.Equal(t, "https://example.com", doc.Markups[2].Attributes["href"])
+	assert.Equal(t, "bar", doc.Atoms[0].Payload["list"].(List)[0].(Map)["foo"])
+	assert.Equal(t, 42.0, doc.Cards[0].Payload["foo"])
+	assert.Equal(t, "foo", doc.Sections[1].Markers[0].Text)
+
+	// check reallocation
+	clone = doc.Clone()
+	clone.Markups = append(clone.Markups, Markup{Tag: "u"})
+	_, err := Compile(doc)
+	assert.NoError(t, err)
+}
+
+func TestCloneForeign(t *testing.T) {
+	markup := &Markup{Tag: "b"}
+	card := &Card{Name: "foo", Payload: Map{}}
+
+	doc := Document{
+		Version: Version,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{
+				{Type: TextMarker, OpenMarkups: []*Markup{markup}, Text: "a"},
+				{Type: TextMarker, ClosedMarkups: 1, Text: "b"},
+			}},
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{
+				{Type: TextMarker, OpenMarkups: []*Markup{markup}, ClosedMarkups: 1, Text: "c"},
+			}},
+			{Type: CardSection, Card: card},
+		},
+	}
+
+	clone := doc.Clone()
+	assert.Equal(t, doc, clone)
+	assert.True(t, clone.Sections[0].Markers[0].OpenMarkups[0] != markup)
+	assert.True(t, clone.Sections[0].Markers[0].OpenMarkups[0] == clone.Sections[1].Markers[0].OpenMarkups[0])
+	assert.True(t, clone.Sections[2].Card != card)
+}
@@ -2,6 +2,7 @@ package mobiledoc
 
 import (
 	"encoding/json"
+	"sort"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
@@ -96,8 +97,8 @@ func (d *Document) MarshalBSONValue() (bsontype.Type, []byte, error) {
 		return 0, nil, err
 	}
 
-	// marshal map
-	val, bytes, err := bson.MarshalValue(raw)
+	// marshal ordered map
+	val, bytes, err := bson.MarshalValue(ordered(raw))
 	if err != nil {
 		return 0, nil, err
 	}
@@ -110,3 +111,47 @@ func (d *Document) IsZero() bool {
 	return d == nil || (d.Version == "" && len(d.Markups) == 0 &&
 		len(d.Atoms) == 0 && len(d.Cards) == 0 && len(d.Sections) == 0)
 }
+
+func ordered(v interface{}) interface{} {
+	// convert maps to ordered documents and lists recursively, maps are not
+	// modified in place as they may be shared with the document
+	switch v := v.(type) {
+	case Map:
+		return orderedMap(v)
+	case bson.M:
+		return orderedMap(v)
+	case List:
+		list := make(bson.A, len(v))
+		for i, item := range v {
+			list[i] = ordered(item)
+		}
+		return list
+	case bson.A:
+		list := make(bson.A, len(v))
+		for i, item := range v {
+			list[i] = ordered(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+func orderedMap(m Map) bson.D {
+	// collect keys
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	// sort keys
+	sort.Strings(keys)
+
+	// build document
+	doc := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		doc = append(doc, bson.E{Key: key, Value: ordered(m[key])})
+	}
+
+	return doc
+}
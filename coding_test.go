@@ -93,6 +93,23 @@ func TestBSONNil(t *testing.T) {
 	})
 }
 
+func TestBSONStable(t *testing.T) {
+	doc := sampleDoc()
+	doc.Atoms[0].Payload = Map{"a": 1, "b": Map{"c": 2, "d": 3}, "e": List{Map{"f": 4, "g": 5}}}
+	doc.Cards[0].Payload = Map{"a": 1, "b": 2, "c": 3, "d": 4}
+
+	first, err := bson.Marshal(docStruct{Doc: &doc})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		out, err := bson.Marshal(docStruct{Doc: &doc})
+		require.NoError(t, err)
+		assert.Equal(t, first, out)
+	}
+
+	assert.Equal(t, Map{"a": 1, "b": Map{"c": 2, "d": 3}, "e": List{Map{"f": 4, "g": 5}}}, doc.Atoms[0].Payload)
+}
+
 func equalMaps(t *testing.T, m1, m2 Map) {
 	o1, e1 := json.Marshal(m1)
 	o2, e2 := json.Marshal(m2)
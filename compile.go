@@ -1,11 +1,30 @@
 package mobiledoc
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 const scratchSize = 128
 
-// Compile will compile provided document into its raw structure.
+// CompileOptions defines options for compiling a document.
+type CompileOptions struct {
+	// Original may be set to the raw document the document has been parsed
+	// from. Markup attributes are then emitted in the order used by the markup
+	// at the same index with the same tag in the original document. Attributes
+	// missing from the original order are emitted afterwards in sorted order.
+	Original Map
+}
+
+// Compile will compile provided document into its raw structure. Attributes
+// are emitted in sorted order to keep the result stable.
 func Compile(doc Document) (Map, error) {
+	return CompileWith(doc, CompileOptions{})
+}
+
+// CompileWith will compile provided document into its raw structure using the
+// specified options.
+func CompileWith(doc Document, opts CompileOptions) (Map, error) {
 	// validate document
 	err := formatValidator.Validate(doc)
 	if err != nil {
@@ -13,7 +32,7 @@ func Compile(doc Document) (Map, error) {
 	}
 
 	// prepare compiler
-	compiler := compiler{doc: doc}
+	compiler := compiler{doc: doc, opts: opts}
 
 	// compile document
 	result := compiler.compile()
@@ -26,6 +45,7 @@ func Compile(doc Document) (Map, error) {
 
 type compiler struct {
 	doc     Document
+	opts    CompileOptions
 	scratch List
 	errors  []error
 }
@@ -46,7 +66,7 @@ func (c *compiler) compileMarkups(markups []Markup) List {
 		if len(markup.Attributes) > 0 {
 			l := c.allocate(2)
 			l[0] = markup.Tag
-			l[1] = c.mapToList(markup.Attributes)
+			l[1] = c.mapToList(markup.Attributes, c.markupOrder(i, markup.Tag))
 			list[i] = l
 		} else {
 			l := c.allocate(1)
@@ -85,10 +105,13 @@ func (c *compiler) compileSections(sections []Section) List {
 	for i, section := range sections {
 		switch section.Type {
 		case MarkupSection:
-			l := c.allocate(3)
+			l := c.allocate(c.sectionLength(section))
 			l[0] = section.Type
 			l[1] = section.Tag
 			l[2] = c.compileMarkers(section.Markers)
+			if len(l) == 4 {
+				l[3] = c.mapToList(section.Attributes, nil)
+			}
 			list[i] = l
 		case ImageSection:
 			l := c.allocate(2)
@@ -96,10 +119,13 @@ func (c *compiler) compileSections(sections []Section) List {
 			l[1] = section.Source
 			list[i] = l
 		case ListSection:
-			l := c.allocate(3)
+			l := c.allocate(c.sectionLength(section))
 			l[0] = section.Type
 			l[1] = section.Tag
 			l[2] = c.compileItems(section.Items)
+			if len(l) == 4 {
+				l[3] = c.mapToList(section.Attributes, nil)
+			}
 			list[i] = l
 		case CardSection:
 			l := c.allocate(2)
@@ -111,6 +137,14 @@ func (c *compiler) compileSections(sections []Section) List {
 	return list
 }
 
+func (c *compiler) sectionLength(section Section) int {
+	// attributes are only emitted for 0.3.2 documents
+	if c.doc.Version == Version032 && len(section.Attributes) > 0 {
+		return 4
+	}
+	return 3
+}
+
 func (c *compiler) compileMarkers(markers []Marker) List {
 	list := c.allocate(len(markers))
 	for i, marker := range markers {
@@ -180,18 +214,91 @@ func (c *compiler) atomIndex(atom *Atom) int {
 	return -1
 }
 
-func (c *compiler) mapToList(m Map) List {
+func (c *compiler) markupOrder(index int, tag string) []string {
+	// check original
+	if c.opts.Original == nil {
+		return nil
+	}
+
+	// get original markup
+	markups, ok := toList(c.opts.Original["markups"])
+	if !ok || index >= len(markups) {
+		return nil
+	}
+	markup, ok := toList(markups[index])
+	if !ok || len(markup) != 2 || markup[0] != tag {
+		return nil
+	}
+
+	// get attributes
+	attributes, ok := toList(markup[1])
+	if !ok {
+		return nil
+	}
+
+	// capture order
+	_, order, err := parseAttributes(attributes)
+	if err != nil {
+		return nil
+	}
+
+	return order
+}
+
+func (c *compiler) mapToList(m Map, order []string) List {
 	list := c.allocate(len(m) * 2)
 	i := 0
-	for key, value := range m {
+
+	// add keys in original order
+	for _, key := range order {
+		value, ok := m[key]
+		if !ok || listHasKey(list[:i], key) {
+			continue
+		}
 		list[i] = key
 		i++
 		list[i] = value
 		i++
 	}
+
+	// return if complete
+	if i == len(list) {
+		return list
+	}
+
+	// collect remaining keys
+	keys := make([]string, 0, len(m)-i/2)
+	for key := range m {
+		if !listHasKey(list[:i], key) {
+			keys = append(keys, key)
+		}
+	}
+
+	// sort keys
+	sort.Strings(keys)
+
+	// add remaining keys
+	for _, key := range keys {
+		list[i] = key
+		i++
+		list[i] = m[key]
+		i++
+	}
+
 	return list
 }
 
+func listHasKey(list List, key string) bool {
+	// check keys
+	for i := 0; i < len(list); i += 2 {
+		if list[i] == key {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *compiler) allocate(length int) List {
 	// check empty
 	if length == 0 {
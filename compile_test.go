@@ -26,6 +26,89 @@ func TestCompile(t *testing.T) {
 	}, m)
 }
 
+func TestCompileSectionAttributes(t *testing.T) {
+	doc := Document{
+		Version: Version032,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Attributes: Map{"data-md-text-align": "center"}},
+			{Type: MarkupSection, Tag: "p"},
+			{Type: ListSection, Tag: "ul", Attributes: Map{"data-md-text-align": "right"}},
+		},
+	}
+
+	m, err := Compile(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, Map{
+		"version": Version032,
+		"markups": List{},
+		"atoms":   List{},
+		"cards":   List{},
+		"sections": List{
+			List{MarkupSection, "p", List{}, List{"data-md-text-align", "center"}},
+			List{MarkupSection, "p", List{}},
+			List{ListSection, "ul", List{}, List{"data-md-text-align", "right"}},
+		},
+	}, m)
+
+	doc.Version = Version
+	_, err = Compile(doc)
+	assert.Error(t, err)
+}
+
+func TestCompileAttributeOrder(t *testing.T) {
+	raw := Map{
+		"version": Version,
+		"markups": List{
+			List{"a", List{"rel", "nofollow", "href", "https://example.com", "target", "_blank"}},
+		},
+	}
+
+	doc, err := Parse(raw)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		c := compiler{doc: doc}
+		assert.Equal(t, List{
+			List{"a", List{"href", "https://example.com", "rel", "nofollow", "target", "_blank"}},
+		}, c.compileMarkups(doc.Markups))
+	}
+
+	c := compiler{doc: doc, opts: CompileOptions{Original: raw}}
+	assert.Equal(t, List{
+		List{"a", List{"rel", "nofollow", "href", "https://example.com", "target", "_blank"}},
+	}, c.compileMarkups(doc.Markups))
+
+	markup := doc.Markups[0]
+	markup.Attributes["class"] = "link"
+	delete(markup.Attributes, "target")
+
+	c = compiler{doc: doc, opts: CompileOptions{Original: raw}}
+	assert.Equal(t, List{
+		List{"a", List{"rel", "nofollow", "href", "https://example.com", "class", "link"}},
+	}, c.compileMarkups(doc.Markups))
+
+	doc.Markups[0].Tag = "b"
+	c = compiler{doc: doc, opts: CompileOptions{Original: raw}}
+	assert.Equal(t, List{
+		List{"b", List{"class", "link", "href", "https://example.com", "rel", "nofollow"}},
+	}, c.compileMarkups(doc.Markups))
+
+	m, err := CompileWith(sampleDoc(), CompileOptions{Original: sampleMap()})
+	assert.NoError(t, err)
+	assert.Equal(t, sampleMap(), m)
+
+	parsed, err := Parse(raw)
+	assert.NoError(t, err)
+	other, err := Parse(Map{
+		"version": Version,
+		"markups": List{
+			List{"a", List{"target", "_blank", "href", "https://example.com", "rel", "nofollow"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, parsed, other)
+}
+
 func BenchmarkCompile(b *testing.B) {
 	in := sampleDoc()
 	b.ReportAllocs()
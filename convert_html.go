@@ -0,0 +1,431 @@
+package mobiledoc
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var htmlWhitespace = regexp.MustCompile(`[ \t\n\r\f]+`)
+
+var htmlDropped = []string{
+	"head", "iframe", "noscript", "object", "script", "style", "template", "title",
+}
+
+var htmlBlocks = []string{
+	"address", "article", "aside", "blockquote", "dd", "details", "div", "dl", "dt",
+	"fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5",
+	"h6", "header", "hr", "li", "main", "nav", "ol", "p", "pre", "section", "summary",
+	"table", "tbody", "td", "tfoot", "th", "thead", "tr", "ul",
+}
+
+// HTMLElement describes an HTML element passed to element handlers.
+type HTMLElement struct {
+	// The tag name of the element.
+	Tag string
+
+	// The attributes of the element.
+	Attributes Map
+
+	// The text content of the element.
+	Text string
+}
+
+// HTMLOptions defines options for converting HTML.
+type HTMLOptions struct {
+	// Validator defines the allowed sections and markups. The converted
+	// document is also validated using the validator. If missing,
+	// NewDefaultValidator is used.
+	Validator *Validator
+
+	// Cards defines the element handlers that convert elements to cards with
+	// the tag as the key. A handler may return false to convert the element
+	// normally.
+	Cards map[string]func(HTMLElement) (Card, bool)
+
+	// Atoms defines the element handlers that convert elements to atoms with
+	// the tag as the key. A handler may return false to convert the element
+	// normally.
+	Atoms map[string]func(HTMLElement) (Atom, bool)
+
+	// Schemes defines the allowed URL schemes for URL-valued markup attributes
+	// and image sources. Relative URLs are always allowed. Markups and images
+	// with a disallowed URL are dropped. If missing, DefaultSchemes is used.
+	Schemes []string
+}
+
+// ConvertHTML will convert HTML read from the provided reader to a document.
+// Block elements that are allowed by the validator are converted to markup,
+// list and image sections while inline elements are converted to markups.
+// Markup attributes not permitted by the validator are removed and markups or
+// images with a URL using a disallowed scheme are dropped. Other elements
+// are unwrapped and their content is converted to paragraphs, except for
+// scripts, styles and similar elements, which are dropped entirely. Nested
+// lists are flattened and images and cards within lists are added after the
+// list.
+func ConvertHTML(r io.Reader, opts HTMLOptions) (Document, error) {
+	// parse html
+	root, err := html.Parse(r)
+	if err != nil {
+		return Document{}, err
+	}
+
+	// ensure validator
+	if opts.Validator == nil {
+		opts.Validator = NewDefaultValidator()
+	}
+
+	// ensure schemes
+	if opts.Schemes == nil {
+		opts.Schemes = DefaultSchemes
+	}
+
+	// prepare converter
+	c := htmlConverter{
+		opts:    opts,
+		builder: NewBuilder(),
+		tag:     "p",
+	}
+	c.builder.validator = opts.Validator
+
+	// convert nodes
+	c.convert(root, nil)
+	c.flush()
+
+	return c.builder.Build()
+}
+
+type htmlConverter struct {
+	opts    HTMLOptions
+	builder *Builder
+	tag     string
+	section bool
+	list    bool
+	pre     bool
+	runs    []htmlRun
+	blocks  []htmlBlock
+}
+
+type htmlRun struct {
+	text    string
+	atom    *Atom
+	markups []Markup
+}
+
+type htmlBlock struct {
+	image string
+	card  *Card
+}
+
+func (c *htmlConverter) convert(node *html.Node, markups []Markup) {
+	switch node.Type {
+	case html.TextNode:
+		c.text(node.Data, markups)
+	case html.ElementNode:
+		c.element(node, markups)
+	case html.DocumentNode:
+		c.children(node, markups)
+	}
+}
+
+func (c *htmlConverter) children(node *html.Node, markups []Markup) {
+	// convert all children
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		c.convert(child, markups)
+	}
+}
+
+func (c *htmlConverter) text(text string, markups []Markup) {
+	// collapse whitespace
+	if !c.pre {
+		text = htmlWhitespace.ReplaceAllString(text, " ")
+	}
+
+	// add run
+	if text != "" {
+		c.runs = append(c.runs, htmlRun{text: text, markups: markups})
+	}
+}
+
+func (c *htmlConverter) element(node *html.Node, markups []Markup) {
+	// get tag
+	tag := node.Data
+
+	// drop element
+	if contains(htmlDropped, tag) && c.opts.Cards[tag] == nil && c.opts.Atoms[tag] == nil {
+		return
+	}
+
+	// handle cards
+	if handler, ok := c.opts.Cards[tag]; ok {
+		card, ok := handler(htmlElement(node))
+		if ok {
+			c.block(htmlBlock{card: &card})
+			return
+		}
+	}
+
+	// handle atoms
+	if handler, ok := c.opts.Atoms[tag]; ok {
+		atom, ok := handler(htmlElement(node))
+		if ok {
+			c.runs = append(c.runs, htmlRun{atom: &atom, markups: markups})
+			return
+		}
+	}
+
+	// handle line breaks
+	if tag == "br" {
+		c.runs = append(c.runs, htmlRun{text: "\n", markups: markups})
+		return
+	}
+
+	// handle images
+	if tag == "img" {
+		source := htmlAttribute(node, "src")
+		if c.opts.Validator.ImageSection != nil && c.opts.Validator.ImageSection(source) && allowedURL(source, c.opts.Schemes) {
+			c.block(htmlBlock{image: source})
+		}
+		return
+	}
+
+	// handle lists
+	if (tag == "ul" || tag == "ol") && !c.list && contains(c.opts.Validator.ListSections, tag) {
+		c.flush()
+		c.builder.List(tag)
+		c.list = true
+		c.children(node, markups)
+		c.flush()
+		c.list = false
+
+		// add queued blocks
+		blocks := c.blocks
+		c.blocks = nil
+		for _, block := range blocks {
+			c.block(block)
+		}
+
+		return
+	}
+
+	// handle list items
+	if tag == "li" && c.list {
+		c.flush()
+		c.children(node, markups)
+		c.flush()
+		return
+	}
+
+	// handle markup sections
+	if contains(c.opts.Validator.MarkupSections, tag) && !c.section && !c.list {
+		c.flush()
+		prev := c.tag
+		c.tag = tag
+		c.section = true
+		c.children(node, markups)
+		c.flush()
+		c.tag = prev
+		c.section = false
+		return
+	}
+
+	// handle other blocks
+	if contains(htmlBlocks, tag) {
+		c.flush()
+		pre := c.pre
+		c.pre = pre || tag == "pre"
+		c.children(node, markups)
+		c.flush()
+		c.pre = pre
+		return
+	}
+
+	// handle markups
+	if validator, ok := c.opts.Validator.Markups[tag]; ok {
+		markup := Markup{Tag: tag}
+		attributes, ok := htmlAttributes(node, validator, c.opts.Schemes)
+		markup.Attributes = attributes
+		if ok && (markup.Attributes != nil || validator == nil || validator(Map{})) {
+			markups = append(markups[:len(markups):len(markups)], markup)
+		}
+	}
+
+	// convert children
+	c.children(node, markups)
+}
+
+func (c *htmlConverter) block(block htmlBlock) {
+	// queue blocks within lists
+	if c.list {
+		c.blocks = append(c.blocks, block)
+		return
+	}
+
+	// add image or card section
+	c.flush()
+	if block.card != nil {
+		c.builder.Card(block.card.Name, block.card.Payload)
+	} else {
+		c.builder.Image(block.image)
+	}
+}
+
+func (c *htmlConverter) flush() {
+	// get runs
+	runs := c.runs
+	c.runs = nil
+
+	// trim whitespace
+	if !c.pre {
+		runs = trimRuns(runs)
+	}
+	if len(runs) == 0 {
+		return
+	}
+
+	// add section or item
+	if c.list {
+		c.builder.Item()
+	} else {
+		c.builder.Section(c.tag)
+	}
+
+	// add markers
+	for _, run := range runs {
+		if run.atom != nil {
+			c.builder.Atom(run.atom.Name, run.atom.Text, run.atom.Payload, run.markups...)
+		} else {
+			c.builder.Text(run.text, run.markups...)
+		}
+	}
+}
+
+func trimRuns(runs []htmlRun) []htmlRun {
+	// prepare list
+	list := make([]htmlRun, 0, len(runs))
+
+	// remove leading and duplicate whitespace and merge text runs
+	space := true
+	for _, run := range runs {
+		if run.atom == nil {
+			if space {
+				run.text = strings.TrimLeft(run.text, " ")
+			}
+			if run.text == "" {
+				continue
+			}
+			space = strings.HasSuffix(run.text, " ") || strings.HasSuffix(run.text, "\n")
+		} else {
+			space = false
+		}
+		if n := len(list); n > 0 && run.atom == nil && list[n-1].atom == nil && equalMarkups(list[n-1].markups, run.markups) {
+			list[n-1].text += run.text
+			continue
+		}
+		list = append(list, run)
+	}
+
+	// remove trailing whitespace
+	for len(list) > 0 && list[len(list)-1].atom == nil {
+		last := &list[len(list)-1]
+		last.text = strings.TrimRight(last.text, " \n")
+		if last.text != "" {
+			break
+		}
+		list = list[:len(list)-1]
+	}
+
+	// remove whitespace before line breaks
+	for i := range list {
+		list[i].text = strings.ReplaceAll(list[i].text, " \n", "\n")
+		if i+1 < len(list) && list[i].atom == nil && strings.HasPrefix(list[i+1].text, "\n") {
+			list[i].text = strings.TrimRight(list[i].text, " ")
+		}
+	}
+
+	return list
+}
+
+func equalMarkups(a, b []Markup) bool {
+	// check length
+	if len(a) != len(b) {
+		return false
+	}
+
+	// check markups
+	for i := range a {
+		if a[i].Tag != b[i].Tag || !equalAttributes(a[i].Attributes, b[i].Attributes) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func htmlElement(node *html.Node) HTMLElement {
+	// collect text
+	var text strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(node)
+
+	// get attributes
+	attributes := Map{}
+	for _, attr := range node.Attr {
+		attributes[attr.Key] = attr.Val
+	}
+
+	return HTMLElement{
+		Tag:        node.Data,
+		Attributes: attributes,
+		Text:       text.String(),
+	}
+}
+
+func htmlAttribute(node *html.Node, key string) string {
+	// find attribute
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+func htmlAttributes(node *html.Node, validator func(Map) bool, schemes []string) (Map, bool) {
+	// collect attributes individually permitted by the validator, a missing
+	// validator permits all attributes
+	var attributes Map
+	for _, attr := range node.Attr {
+		if validator != nil && !validator(Map{attr.Key: attr.Val}) {
+			continue
+		}
+
+		// drop element if the url is not allowed
+		if !allowedAttribute(attr.Key, attr.Val, schemes) {
+			return nil, false
+		}
+
+		if attributes == nil {
+			attributes = Map{}
+		}
+		attributes[attr.Key] = attr.Val
+	}
+
+	// check combination
+	if attributes != nil && validator != nil && !validator(attributes) {
+		return nil, true
+	}
+
+	return attributes, true
+}
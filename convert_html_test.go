@@ -0,0 +1,182 @@
+package mobiledoc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertHTML(t *testing.T) {
+	src := `<html><head><title>Title</title><style>p { color: red; }</style></head><body>
+		<h1>Hello <em>World</em></h1>
+		<p>Some   <strong>bold</strong> and <a href="https://example.com" target="_blank" onclick="evil()">linked</a> text. <br>Next line.</p>
+		<div>Loose <span class="x">text</span> <font>here</font></div>
+		<script>alert("x")</script>
+		<blockquote><p>Quoted</p></blockquote>
+		<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul>
+		<ol><li><p>first</p></li></ol>
+		<img src="https://example.com/foo.png">
+		<h6>Small</h6>
+		<pre>  code
+  block</pre>
+	</body></html>`
+
+	doc, err := ConvertHTML(strings.NewReader(src), HTMLOptions{})
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Heading(1).Text("Hello ").Text("World", Emphasis).
+		Paragraph().
+		Text("Some ").
+		Text("bold", Strong).
+		Text(" and ").
+		Text("linked", Link("https://example.com")).
+		Text(" text.\nNext line.").
+		Paragraph().Text("Loose text here").
+		Section("blockquote").Text("Quoted").
+		List("ul", "one", "two", "nested").
+		List("ol", "first").
+		Image("https://example.com/foo.png").
+		Heading(6).Text("Small").
+		Paragraph().Text("  code\n  block").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestConvertHTMLValidator(t *testing.T) {
+	validator := NewEmptyValidator()
+	validator.MarkupSections = []string{"p"}
+	validator.Markups["b"] = NoAttributesValidator
+
+	doc, err := ConvertHTML(strings.NewReader(`<h1>Title</h1><ul><li><b>Item</b> <i>text</i></li></ul><img src="foo.png">`), HTMLOptions{
+		Validator: validator,
+	})
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Paragraph().Text("Title").
+		Paragraph().Text("Item", Bold).Text(" text").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestConvertHTMLNilMarkupValidator(t *testing.T) {
+	validator := NewEmptyValidator()
+	validator.MarkupSections = []string{"p"}
+	validator.Markups["b"] = nil
+
+	doc, err := ConvertHTML(strings.NewReader(`<p><b class="x">hi</b> <b>there</b></p>`), HTMLOptions{
+		Validator: validator,
+	})
+	assert.NoError(t, err)
+
+	builder := NewBuilder()
+	builder.validator = validator
+	expected, err := builder.
+		Paragraph().
+		Text("hi", Markup{Tag: "b", Attributes: Map{"class": "x"}}).
+		Text(" ").
+		Text("there", Bold).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestConvertHTMLListBlocks(t *testing.T) {
+	src := `<ol><li>a<img src="/foo.png">b</li><li>c<iframe src="/embed"></iframe></li></ol><p>d</p>`
+
+	validator := NewDefaultValidator()
+	validator.UnknownCards = true
+
+	doc, err := ConvertHTML(strings.NewReader(src), HTMLOptions{
+		Validator: validator,
+		Cards: map[string]func(HTMLElement) (Card, bool){
+			"iframe": func(el HTMLElement) (Card, bool) {
+				return Card{Name: "embed", Payload: Map{"src": el.Attributes["src"]}}, true
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		List("ol", "ab", "c").
+		Image("/foo.png").
+		Card("embed", Map{"src": "/embed"}).
+		Paragraph().Text("d").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestConvertHTMLSchemes(t *testing.T) {
+	src := `<p><a href="javascript:alert(1)">a</a> <a href=" JavaScript:alert(1)">b</a> <a href="/c">c</a></p>` +
+		`<img src="javascript:alert(1)"><img src="data:image/png;base64,AAAA"><img src="/foo.png">`
+
+	doc, err := ConvertHTML(strings.NewReader(src), HTMLOptions{})
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Paragraph().Text("a b ").Text("c", Link("/c")).
+		Image("/foo.png").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+
+	doc, err = ConvertHTML(strings.NewReader(`<a href="ftp://example.com">a</a>`), HTMLOptions{
+		Schemes: []string{"ftp"},
+	})
+	assert.NoError(t, err)
+
+	expected, err = NewBuilder().
+		Paragraph().Text("a", Link("ftp://example.com")).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestConvertHTMLHandlers(t *testing.T) {
+	validator := NewDefaultValidator()
+	validator.UnknownAtoms = true
+	validator.UnknownCards = true
+
+	doc, err := ConvertHTML(strings.NewReader(`<p>Hi <span data-mention="1"><b>@joe</b></span> <span>there</span></p><iframe src="https://example.com/embed"></iframe>`), HTMLOptions{
+		Validator: validator,
+		Cards: map[string]func(HTMLElement) (Card, bool){
+			"iframe": func(el HTMLElement) (Card, bool) {
+				return Card{Name: "embed", Payload: Map{"url": el.Attributes["src"]}}, true
+			},
+		},
+		Atoms: map[string]func(HTMLElement) (Atom, bool){
+			"span": func(el HTMLElement) (Atom, bool) {
+				id, ok := el.Attributes["data-mention"]
+				if !ok {
+					return Atom{}, false
+				}
+				return Atom{Name: "mention", Text: el.Text, Payload: Map{"id": id}}, true
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Paragraph().
+		Text("Hi ").
+		Atom("mention", "@joe", Map{"id": "1"}).
+		Text(" there").
+		Card("embed", Map{"url": "https://example.com/embed"}).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+
+	_, err = ConvertHTML(strings.NewReader(`<iframe src="foo"></iframe>`), HTMLOptions{
+		Cards: map[string]func(HTMLElement) (Card, bool){
+			"iframe": func(el HTMLElement) (Card, bool) {
+				return Card{Name: "embed", Payload: Map{}}, true
+			},
+		},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,587 @@
+package mobiledoc
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	markdownFence       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+	markdownHeading     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))??(?:[ \t]+#+)?[ \t]*$`)
+	markdownSetext      = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	markdownBreak       = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	markdownQuote       = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	markdownBullet      = regexp.MustCompile(`^([ \t]*)([-+*])(?:[ \t]+(.*))?$`)
+	markdownOrdered     = regexp.MustCompile(`^([ \t]*)([0-9]{1,9})[.)](?:[ \t]+(.*))?$`)
+	markdownImage       = regexp.MustCompile(`^!\[([^\]]*)\]\(\s*<?([^\s>)]*)>?(?:\s+"[^"]*")?\s*\)$`)
+	markdownAutolink    = regexp.MustCompile(`^<((?:https?://|mailto:)[^\s<>]*)>`)
+	markdownPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// MarkdownOptions defines options for converting Markdown.
+type MarkdownOptions struct {
+	// CodeCard is called to create a card for fenced and indented code blocks.
+	// If missing, code blocks are converted to paragraphs with a code markup.
+	CodeCard func(language, code string) Card
+
+	// ImageCard is called to create a card for images that stand alone in a
+	// paragraph. If missing, images are converted to image sections.
+	ImageCard func(source, alt string) Card
+
+	// Schemes defines the allowed URL schemes for links and images. Relative
+	// URLs are always allowed. Links with a disallowed destination are
+	// converted to text and images with a disallowed source are dropped. If
+	// missing, DefaultSchemes is used.
+	Schemes []string
+}
+
+// ConvertMarkdown will convert a Markdown text to a document. Headings,
+// paragraphs, block quotes, lists, images and code blocks are converted to
+// sections while emphasis, strong emphasis, code spans, strikethrough and links
+// are converted to markups. Nested lists are flattened and unsupported blocks
+// are dropped. The document uses only sections and markups supported by
+// NewDefaultValidator.
+func ConvertMarkdown(src string) (Document, error) {
+	return ConvertMarkdownWith(src, MarkdownOptions{})
+}
+
+// ConvertMarkdownWith will convert a Markdown text to a document using the
+// specified options.
+func ConvertMarkdownWith(src string, opts MarkdownOptions) (Document, error) {
+	// ensure schemes
+	if opts.Schemes == nil {
+		opts.Schemes = DefaultSchemes
+	}
+
+	// prepare converter
+	c := markdownConverter{
+		opts:    opts,
+		builder: NewBuilder(),
+		lines:   strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n"),
+	}
+
+	// convert blocks
+	c.convert()
+
+	return c.builder.Build()
+}
+
+type markdownConverter struct {
+	opts      MarkdownOptions
+	builder   *Builder
+	lines     []string
+	paragraph []string
+}
+
+func (c *markdownConverter) convert() {
+	for i := 0; i < len(c.lines); i++ {
+		line := c.lines[i]
+
+		// handle blank lines
+		if strings.TrimSpace(line) == "" {
+			c.flush()
+			continue
+		}
+
+		// handle setext headings
+		if len(c.paragraph) > 0 {
+			if match := markdownSetext.FindStringSubmatch(line); match != nil {
+				level := 1
+				if match[1][0] == '-' {
+					level = 2
+				}
+				c.builder.Heading(level)
+				c.inline(strings.Join(c.paragraph, " "), nil)
+				c.paragraph = nil
+				continue
+			}
+		}
+
+		// handle thematic breaks
+		if markdownBreak.MatchString(line) {
+			c.flush()
+			continue
+		}
+
+		// handle fenced code blocks
+		if match := markdownFence.FindStringSubmatch(line); match != nil {
+			c.flush()
+			i = c.fence(i, match[1], match[2])
+			continue
+		}
+
+		// handle indented code blocks
+		if len(c.paragraph) == 0 && indentation(line) >= 4 {
+			i = c.indented(i)
+			continue
+		}
+
+		// handle headings
+		if match := markdownHeading.FindStringSubmatch(line); match != nil {
+			c.flush()
+			c.builder.Heading(len(match[1]))
+			c.inline(match[2], nil)
+			continue
+		}
+
+		// handle block quotes
+		if markdownQuote.MatchString(line) {
+			c.flush()
+			i = c.quote(i)
+			continue
+		}
+
+		// handle lists
+		if markdownBullet.MatchString(line) || markdownOrdered.MatchString(line) {
+			c.flush()
+			i = c.list(i)
+			continue
+		}
+
+		// add paragraph line
+		c.paragraph = append(c.paragraph, strings.TrimSpace(line))
+	}
+
+	// flush last paragraph
+	c.flush()
+}
+
+func (c *markdownConverter) flush() {
+	// check paragraph
+	if len(c.paragraph) == 0 {
+		return
+	}
+
+	// get text
+	text := strings.Join(c.paragraph, " ")
+	c.paragraph = nil
+
+	// handle standalone images
+	if match := markdownImage.FindStringSubmatch(text); match != nil {
+		if !allowedURL(match[2], c.opts.Schemes) {
+			return
+		} else if c.opts.ImageCard != nil {
+			card := c.opts.ImageCard(match[2], match[1])
+			c.builder.Card(card.Name, card.Payload)
+		} else {
+			c.builder.Image(match[2])
+		}
+		return
+	}
+
+	// add paragraph
+	c.builder.Paragraph()
+	c.inline(text, nil)
+}
+
+func (c *markdownConverter) fence(start int, fence, info string) int {
+	// collect lines until closing fence
+	var code []string
+	end := start + 1
+	for ; end < len(c.lines); end++ {
+		line := strings.TrimSpace(c.lines[end])
+		if strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "" {
+			break
+		}
+		code = append(code, c.lines[end])
+	}
+
+	// get language
+	language := ""
+	if fields := strings.Fields(info); len(fields) > 0 {
+		language = fields[0]
+	}
+
+	// add code
+	c.code(language, strings.Join(code, "\n"))
+
+	return end
+}
+
+func (c *markdownConverter) indented(start int) int {
+	// collect indented and blank lines
+	var code []string
+	end := start
+	for ; end < len(c.lines); end++ {
+		line := c.lines[end]
+		if strings.TrimSpace(line) == "" {
+			code = append(code, "")
+			continue
+		} else if indentation(line) < 4 {
+			break
+		}
+		code = append(code, strings.TrimPrefix(strings.TrimPrefix(line, "    "), "\t"))
+	}
+
+	// remove trailing blank lines
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+
+	// add code
+	c.code("", strings.Join(code, "\n"))
+
+	return end - 1
+}
+
+func (c *markdownConverter) code(language, code string) {
+	// add card
+	if c.opts.CodeCard != nil {
+		card := c.opts.CodeCard(language, code)
+		c.builder.Card(card.Name, card.Payload)
+		return
+	}
+
+	// otherwise add paragraph
+	c.builder.Paragraph().Text(code, Code)
+}
+
+func (c *markdownConverter) quote(start int) int {
+	// collect quoted paragraphs
+	var paragraph []string
+	end := start
+	for ; end < len(c.lines); end++ {
+		match := markdownQuote.FindStringSubmatch(c.lines[end])
+		if match == nil {
+			break
+		}
+
+		// handle blank lines
+		text := strings.TrimSpace(match[1])
+		if text == "" {
+			c.quoteParagraph(paragraph)
+			paragraph = nil
+			continue
+		}
+
+		// add line
+		paragraph = append(paragraph, text)
+	}
+
+	// add last paragraph
+	c.quoteParagraph(paragraph)
+
+	return end - 1
+}
+
+func (c *markdownConverter) quoteParagraph(lines []string) {
+	// add section
+	if len(lines) > 0 {
+		c.builder.Section("blockquote")
+		c.inline(strings.Join(lines, " "), nil)
+	}
+}
+
+func (c *markdownConverter) list(start int) int {
+	// determine type
+	tag := "ul"
+	pattern := markdownBullet
+	if markdownOrdered.MatchString(c.lines[start]) {
+		tag = "ol"
+		pattern = markdownOrdered
+	}
+
+	// collect items
+	var items [][]string
+	end := start
+	for ; end < len(c.lines); end++ {
+		line := c.lines[end]
+
+		// handle blank lines, the list continues if the next line is an item
+		// or indented
+		if strings.TrimSpace(line) == "" {
+			if end+1 < len(c.lines) && (pattern.MatchString(c.lines[end+1]) || indentation(c.lines[end+1]) >= 2) {
+				continue
+			}
+			break
+		}
+
+		// handle items, nested items are flattened
+		if match := pattern.FindStringSubmatch(line); match != nil && !markdownBreak.MatchString(line) {
+			items = append(items, []string{strings.TrimSpace(match[3])})
+			continue
+		} else if match := nestedItem(line); match != nil {
+			if indentation(line) == 0 {
+				break
+			}
+			items = append(items, []string{strings.TrimSpace(match[3])})
+			continue
+		}
+
+		// stop at other blocks
+		if indentation(line) < 2 && (markdownHeading.MatchString(line) || markdownQuote.MatchString(line) ||
+			markdownFence.MatchString(line) || markdownBreak.MatchString(line)) {
+			break
+		}
+
+		// add continuation line
+		items[len(items)-1] = append(items[len(items)-1], strings.TrimSpace(line))
+	}
+
+	// add list
+	c.builder.List(tag)
+	for _, item := range items {
+		c.builder.Item()
+		c.inline(strings.Join(item, " "), nil)
+	}
+
+	return end - 1
+}
+
+func (c *markdownConverter) inline(text string, markups []Markup) {
+	// prepare buffer
+	var buffer strings.Builder
+
+	// prepare flush
+	flush := func() {
+		if buffer.Len() > 0 {
+			c.builder.Text(buffer.String(), markups...)
+			buffer.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		ch := text[i]
+
+		// handle escapes
+		if ch == '\\' && i+1 < len(text) && strings.IndexByte(markdownPunctuation, text[i+1]) >= 0 {
+			buffer.WriteByte(text[i+1])
+			i += 2
+			continue
+		}
+
+		// handle code spans
+		if ch == '`' {
+			run := runLength(text, i)
+			if end := findRun(text, i+run, "`", run); end >= 0 {
+				flush()
+				code := text[i+run : end]
+				if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				c.builder.Text(code, append(markups[:len(markups):len(markups)], Code)...)
+				i = end + run
+				continue
+			}
+			buffer.WriteString(text[i : i+run])
+			i += run
+			continue
+		}
+
+		// handle autolinks
+		if ch == '<' {
+			if match := markdownAutolink.FindStringSubmatch(text[i:]); match != nil {
+				flush()
+				c.builder.Text(match[1], append(markups[:len(markups):len(markups)], Link(match[1]))...)
+				i += len(match[0])
+				continue
+			}
+		}
+
+		// handle links and images
+		if ch == '[' || (ch == '!' && i+1 < len(text) && text[i+1] == '[') {
+			image := ch == '!'
+			offset := i
+			if image {
+				offset++
+			}
+			if label, dest, end, ok := parseMarkdownLink(text, offset); ok {
+				flush()
+				if image || !allowedURL(dest, c.opts.Schemes) {
+					c.inline(label, markups)
+				} else {
+					c.inline(label, append(markups[:len(markups):len(markups)], Link(dest)))
+				}
+				i = end
+				continue
+			}
+		}
+
+		// handle emphasis and strikethrough
+		if ch == '*' || ch == '_' || ch == '~' {
+			run := runLength(text, i)
+			if inner, markup, end, ok := parseMarkdownEmphasis(text, i, run); ok {
+				flush()
+				c.inline(inner, append(markups[:len(markups):len(markups)], markup...))
+				i = end
+				continue
+			}
+			buffer.WriteString(text[i : i+run])
+			i += run
+			continue
+		}
+
+		// add character
+		buffer.WriteByte(ch)
+		i++
+	}
+
+	// flush remaining text
+	flush()
+}
+
+func parseMarkdownLink(text string, start int) (string, string, int, bool) {
+	// find closing bracket
+	depth := 0
+	end := -1
+	for i := start; i < len(text) && end < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+
+	// check destination
+	if end < 0 || end+1 >= len(text) || text[end+1] != '(' {
+		return "", "", 0, false
+	}
+
+	// find closing parenthesis
+	depth = 0
+	close := -1
+	for i := end + 1; i < len(text) && close < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				close = i
+			}
+		}
+	}
+	if close < 0 {
+		return "", "", 0, false
+	}
+
+	// get destination without title
+	dest := strings.TrimSpace(text[end+2 : close])
+	if fields := strings.Fields(dest); len(fields) > 0 {
+		dest = fields[0]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+
+	return text[start+1 : end], dest, close + 1, true
+}
+
+func parseMarkdownEmphasis(text string, start, run int) (string, []Markup, int, bool) {
+	// get delimiter and markups
+	ch := text[start]
+	var delimiter string
+	var markups []Markup
+	switch {
+	case ch == '~' && run == 2:
+		delimiter, markups = "~~", []Markup{Strikethrough}
+	case ch == '~':
+		return "", nil, 0, false
+	case run == 1:
+		delimiter, markups = text[start:start+1], []Markup{Emphasis}
+	case run == 2:
+		delimiter, markups = text[start:start+2], []Markup{Strong}
+	default:
+		delimiter, markups = text[start:start+3], []Markup{Strong, Emphasis}
+	}
+
+	// check left flanking
+	after := start + len(delimiter)
+	if after >= len(text) || isSpace(text[after]) {
+		return "", nil, 0, false
+	} else if ch == '_' && start > 0 && isWord(text[start-1]) {
+		return "", nil, 0, false
+	}
+
+	// find right flanking closer
+	end := findRun(text, after, delimiter[:1], len(delimiter))
+	for end >= 0 {
+		if !isSpace(text[end-1]) && (ch != '_' || end+len(delimiter) >= len(text) || !isWord(text[end+len(delimiter)])) {
+			break
+		}
+		end = findRun(text, end+len(delimiter), delimiter[:1], len(delimiter))
+	}
+	if end < 0 {
+		return "", nil, 0, false
+	}
+
+	return text[after:end], markups, end + len(delimiter), true
+}
+
+func nestedItem(line string) []string {
+	// match either list type
+	if match := markdownBullet.FindStringSubmatch(line); match != nil {
+		return match
+	}
+
+	return markdownOrdered.FindStringSubmatch(line)
+}
+
+func runLength(text string, start int) int {
+	// count repeated characters
+	n := 1
+	for start+n < len(text) && text[start+n] == text[start] {
+		n++
+	}
+
+	return n
+}
+
+func findRun(text string, start int, ch string, length int) int {
+	// find a run of exactly the specified length, skipping escapes and code
+	for i := start; i < len(text); {
+		switch {
+		case text[i] == '\\' && ch != "`":
+			i += 2
+		case text[i] == '`' && ch != "`":
+			run := runLength(text, i)
+			if end := findRun(text, i+run, "`", run); end >= 0 {
+				i = end + run
+			} else {
+				i += run
+			}
+		case text[i] == ch[0]:
+			run := runLength(text, i)
+			if run == length {
+				return i
+			}
+			i += run
+		default:
+			i++
+		}
+	}
+
+	return -1
+}
+
+func indentation(line string) int {
+	// count leading whitespace, tabs count as four spaces
+	n := 0
+	for _, ch := range line {
+		switch ch {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+
+	return n
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n'
+}
+
+func isWord(ch byte) bool {
+	return ch < 128 && (unicode.IsLetter(rune(ch)) || unicode.IsDigit(rune(ch)))
+}
@@ -0,0 +1,115 @@
+package mobiledoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertMarkdown(t *testing.T) {
+	src := "# Title #\n\n" +
+		"Sub\n---\n\n" +
+		"Some *em*, **strong**, ***both***, ~~gone~~ and `code`\nwith [a **link**](https://example.com \"title\").\n\n" +
+		"> quoted _text_\n> more\n>\n> second\n\n" +
+		"- one\n- two\n  continued\n  - nested\n\n" +
+		"1. first\n2) second\n\n" +
+		"***\n\n" +
+		"![alt](https://example.com/foo.png)\n\n" +
+		"```go\nfoo()\nbar()\n```\n\n" +
+		"    indented\n\n" +
+		"Escaped \\*star\\* and snake_case_word and <https://example.com>."
+
+	doc, err := ConvertMarkdown(src)
+	assert.NoError(t, err)
+
+	link := Link("https://example.com")
+	expected, err := NewBuilder().
+		Heading(1).Text("Title").
+		Heading(2).Text("Sub").
+		Paragraph().
+		Text("Some ").
+		Text("em", Emphasis).
+		Text(", ").
+		Text("strong", Strong).
+		Text(", ").
+		Text("both", Strong, Emphasis).
+		Text(", ").
+		Text("gone", Strikethrough).
+		Text(" and ").
+		Text("code", Code).
+		Text(" with ").
+		Text("a ", link).
+		Text("link", link, Strong).
+		Text(".").
+		Section("blockquote").Text("quoted ").Text("text", Emphasis).Text(" more").
+		Section("blockquote").Text("second").
+		List("ul", "one", "two continued", "nested").
+		List("ol", "first", "second").
+		Image("https://example.com/foo.png").
+		Paragraph().Text("foo()\nbar()", Code).
+		Paragraph().Text("indented", Code).
+		Paragraph().Text("Escaped *star* and snake_case_word and ").Text("https://example.com", link).Text(".").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+
+	assert.NoError(t, NewDefaultValidator().Validate(doc))
+}
+
+func TestConvertMarkdownCards(t *testing.T) {
+	doc, err := ConvertMarkdownWith("```go\nfoo()\n```\n\n![alt](foo.png)", MarkdownOptions{
+		CodeCard: func(language, code string) Card {
+			return Card{Name: "code", Payload: Map{"language": language, "code": code}}
+		},
+		ImageCard: func(source, alt string) Card {
+			return Card{Name: "image", Payload: Map{"src": source, "alt": alt}}
+		},
+	})
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Card("code", Map{"language": "go", "code": "foo()"}).
+		Card("image", Map{"src": "foo.png", "alt": "alt"}).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestConvertMarkdownLiterals(t *testing.T) {
+	doc, err := ConvertMarkdown("a * b ** c _d ~e~ `f [g] (h)\n\n")
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Paragraph().Text("a * b ** c _d ~e~ `f [g] (h)").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
+
+func TestConvertMarkdownSchemes(t *testing.T) {
+	src := "[a](javascript:alert(1)) [b](JavaScript:alert(1)) [c](/c) [d](ftp://d)\n\n" +
+		"![x](javascript:void)\n\n" +
+		"![y](data:image/png;base64,AAAA)\n\n" +
+		"![z](/z.png)"
+
+	doc, err := ConvertMarkdown(src)
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Paragraph().Text("a").Text(" ").Text("b").Text(" ").Text("c", Link("/c")).Text(" ").Text("d").
+		Image("/z.png").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+
+	doc, err = ConvertMarkdownWith("[d](ftp://d)", MarkdownOptions{
+		Schemes: []string{"ftp"},
+	})
+	assert.NoError(t, err)
+
+	expected, err = NewBuilder().
+		Paragraph().Text("d", Link("ftp://d")).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, doc)
+}
@@ -0,0 +1,538 @@
+package mobiledoc
+
+import "sort"
+
+// ChangeType defines the type of a section or item change.
+type ChangeType int
+
+// The available change types.
+const (
+	ChangeInserted ChangeType = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+// SectionChange describes the change of a single section.
+type SectionChange struct {
+	// The type of the change.
+	Type ChangeType
+
+	// The index of the section in the old and new document. The old index is
+	// -1 for inserted sections and the new index is -1 for removed sections.
+	OldIndex int
+	NewIndex int
+
+	// The old and new section, if available.
+	Old *Section
+	New *Section
+
+	// The content changes of a modified markup section.
+	Content *ContentChange
+
+	// The item changes of a modified list section.
+	Items []ItemChange
+}
+
+// ItemChange describes the change of a single list item.
+type ItemChange struct {
+	// The type of the change.
+	Type ChangeType
+
+	// The index of the item in the old and new section. The old index is -1
+	// for inserted items and the new index is -1 for removed items.
+	OldIndex int
+	NewIndex int
+
+	// The content changes of a modified item.
+	Content *ContentChange
+}
+
+// ContentChange describes the changes of the markers of a markup section or
+// list item. All offsets are measured in runes and atoms count as a single
+// character.
+type ContentChange struct {
+	Text    []TextChange
+	Markups []MarkupChange
+	Atoms   []AtomChange
+}
+
+// TextChange describes text that has been removed at the old offset and
+// inserted at the new offset.
+type TextChange struct {
+	OldOffset int
+	NewOffset int
+	Removed   string
+	Inserted  string
+}
+
+// MarkupChange describes a markup that has been added to or removed from
+// unchanged text between Start and End (exclusive) in the new content.
+type MarkupChange struct {
+	Start  int
+	End    int
+	Markup Markup
+	Added  bool
+}
+
+// AtomChange describes an atom at the offset of the new content that has been
+// replaced or whose text or payload has changed.
+type AtomChange struct {
+	Offset int
+	Old    Atom
+	New    Atom
+}
+
+// Diff will compare the two documents and return the list of section changes.
+// Sections are aligned by content and unmatched sections of the same type are
+// reported as modified.
+func Diff(old, new Document) []SectionChange {
+	// compute section keys
+	oldKeys := sectionKeys(old.Sections)
+	newKeys := sectionKeys(new.Sections)
+
+	// prepare changes
+	var changes []SectionChange
+
+	// walk alignment
+	alignKeys(oldKeys, newKeys, func(i, j int) {
+		switch {
+		case i < 0:
+			changes = append(changes, SectionChange{
+				Type:     ChangeInserted,
+				OldIndex: -1,
+				NewIndex: j,
+				New:      &new.Sections[j],
+			})
+		case j < 0:
+			changes = append(changes, SectionChange{
+				Type:     ChangeRemoved,
+				OldIndex: i,
+				NewIndex: -1,
+				Old:      &old.Sections[i],
+			})
+		default:
+			changes = append(changes, diffSection(i, j, &old.Sections[i], &new.Sections[j]))
+		}
+	}, func(i, j int) bool {
+		return old.Sections[i].Type == new.Sections[j].Type
+	})
+
+	return changes
+}
+
+func diffSection(i, j int, old, new *Section) SectionChange {
+	// prepare change
+	change := SectionChange{
+		Type:     ChangeModified,
+		OldIndex: i,
+		NewIndex: j,
+		Old:      old,
+		New:      new,
+	}
+
+	// diff content
+	switch old.Type {
+	case MarkupSection:
+		content := diffContent(old.Markers, new.Markers)
+		if !content.empty() {
+			change.Content = &content
+		}
+	case ListSection:
+		change.Items = diffItems(old.Items, new.Items)
+	}
+
+	return change
+}
+
+func diffItems(old, new [][]Marker) []ItemChange {
+	// compute item keys
+	oldKeys := itemKeys(old)
+	newKeys := itemKeys(new)
+
+	// prepare changes
+	var changes []ItemChange
+
+	// walk alignment
+	alignKeys(oldKeys, newKeys, func(i, j int) {
+		switch {
+		case i < 0:
+			changes = append(changes, ItemChange{Type: ChangeInserted, OldIndex: -1, NewIndex: j})
+		case j < 0:
+			changes = append(changes, ItemChange{Type: ChangeRemoved, OldIndex: i, NewIndex: -1})
+		default:
+			content := diffContent(old[i], new[j])
+			changes = append(changes, ItemChange{Type: ChangeModified, OldIndex: i, NewIndex: j, Content: &content})
+		}
+	}, func(int, int) bool {
+		return true
+	})
+
+	return changes
+}
+
+// alignKeys will align the two key lists using their longest common
+// subsequence and call fn for all unmatched entries. Unmatched entries between
+// two matches are paired by aligning compatible entries and reported with both
+// indexes, other entries are reported with -1 as the missing index.
+func alignKeys(a, b []string, fn func(i, j int), compatible func(i, j int) bool) {
+	// compute matches
+	matches := lcs(len(a), len(b), func(i, j int) bool {
+		return a[i] == b[j]
+	})
+
+	// walk gaps between matches
+	i, j := 0, 0
+	for _, match := range append(matches, [2]int{len(a), len(b)}) {
+		// pair compatible entries
+		offsetA, offsetB := i, j
+		pairs := lcs(match[0]-i, match[1]-j, func(x, y int) bool {
+			return compatible(offsetA+x, offsetB+y)
+		})
+
+		// report entries
+		for _, pair := range append(pairs, [2]int{match[0] - offsetA, match[1] - offsetB}) {
+			for ; i < offsetA+pair[0]; i++ {
+				fn(i, -1)
+			}
+			for ; j < offsetB+pair[1]; j++ {
+				fn(-1, j)
+			}
+			if i < match[0] && j < match[1] {
+				fn(i, j)
+				i++
+				j++
+			}
+		}
+
+		// skip match
+		i, j = match[0]+1, match[1]+1
+	}
+}
+
+// lcs returns the index pairs of the longest common subsequence of two lists
+// with the specified lengths. The subsequence is computed in linear space using
+// the divide and conquer variant of Myers' algorithm, which takes time
+// proportional to the combined length times the number of differences.
+func lcs(n, m int, equal func(i, j int) bool) [][2]int {
+	// prepare state
+	l := lcsState{equal: equal}
+
+	// compare lists
+	l.compare(0, n, 0, m)
+
+	return l.matches
+}
+
+type lcsState struct {
+	equal   func(i, j int) bool
+	matches [][2]int
+}
+
+func (l *lcsState) compare(a0, a1, b0, b1 int) {
+	// match common prefix
+	for a0 < a1 && b0 < b1 && l.equal(a0, b0) {
+		l.matches = append(l.matches, [2]int{a0, b0})
+		a0++
+		b0++
+	}
+
+	// find common suffix
+	suffix := 0
+	for a1-suffix > a0 && b1-suffix > b0 && l.equal(a1-suffix-1, b1-suffix-1) {
+		suffix++
+	}
+
+	// compare remainder
+	if a0 < a1-suffix && b0 < b1-suffix {
+		x, y, u, v := l.middleSnake(a0, a1-suffix, b0, b1-suffix)
+		l.compare(a0, x, b0, y)
+		for ; x < u; x, y = x+1, y+1 {
+			l.matches = append(l.matches, [2]int{x, y})
+		}
+		l.compare(u, a1-suffix, v, b1-suffix)
+	}
+
+	// match common suffix
+	for k := suffix; k > 0; k-- {
+		l.matches = append(l.matches, [2]int{a1 - k, b1 - k})
+	}
+}
+
+func (l *lcsState) middleSnake(a0, a1, b0, b1 int) (int, int, int, int) {
+	// get sizes
+	n := a1 - a0
+	m := b1 - b0
+	delta := n - m
+	odd := delta%2 != 0
+	size := (n + m + 1) / 2
+
+	// prepare furthest reaching x positions per diagonal k = x - y for the
+	// forward and backward search
+	forward := make([]int, 2*size+3)
+	backward := make([]int, 2*size+3)
+	fo := size + 1
+	bo := size + 1 - delta
+	forward[fo+1] = 0
+	backward[bo+delta-1] = n
+
+	// the paths always overlap within size steps
+	for d := 0; ; d++ {
+		// search forward
+		for k := -d; k <= d; k += 2 {
+			// step down or right
+			var x int
+			if k == -d || (k != d && forward[fo+k-1] < forward[fo+k+1]) {
+				x = forward[fo+k+1]
+			} else {
+				x = forward[fo+k-1] + 1
+			}
+			y := x - k
+
+			// follow snake
+			sx, sy := x, y
+			for x < n && y < m && l.equal(a0+x, b0+y) {
+				x++
+				y++
+			}
+			forward[fo+k] = x
+
+			// check overlap
+			if odd && k >= delta-(d-1) && k <= delta+(d-1) && x >= backward[bo+k] {
+				return a0 + sx, b0 + sy, a0 + x, b0 + y
+			}
+		}
+
+		// search backward
+		for k := delta - d; k <= delta+d; k += 2 {
+			// step up or left
+			var x int
+			if k == delta+d || (k != delta-d && backward[bo+k-1] < backward[bo+k+1]-1) {
+				x = backward[bo+k-1]
+			} else {
+				x = backward[bo+k+1] - 1
+			}
+			y := x - k
+
+			// follow snake
+			ex, ey := x, y
+			for x > 0 && y > 0 && l.equal(a0+x-1, b0+y-1) {
+				x--
+				y--
+			}
+			backward[bo+k] = x
+
+			// check overlap
+			if !odd && k >= -d && k <= d && x <= forward[fo+k] {
+				return a0 + x, b0 + y, a0 + ex, b0 + ey
+			}
+		}
+	}
+}
+
+func diffContent(old, new []Marker) ContentChange {
+	// get contents
+	a := contentOf(old)
+	b := contentOf(new)
+
+	// prepare change
+	var change ContentChange
+	var markups = map[string]int{}
+
+	// compute matches
+	matches := lcs(len(a.text), len(b.text), func(i, j int) bool {
+		return a.text[i] == b.text[j]
+	})
+
+	// walk matches
+	i, j := 0, 0
+	for _, match := range append(matches, [2]int{len(a.text), len(b.text)}) {
+		// add text change
+		if i < match[0] || j < match[1] {
+			change.Text = append(change.Text, TextChange{
+				OldOffset: i,
+				NewOffset: j,
+				Removed:   string(a.text[i:match[0]]),
+				Inserted:  string(b.text[j:match[1]]),
+			})
+		}
+
+		// check end
+		if match[0] == len(a.text) {
+			break
+		}
+		i, j = match[0], match[1]
+
+		// compare markups
+		for _, key := range b.markups[j] {
+			if !contains(a.markups[i], key) {
+				change.addMarkup(markups, j, b.values[key], key, true)
+			}
+		}
+		for _, key := range a.markups[i] {
+			if !contains(b.markups[j], key) {
+				change.addMarkup(markups, j, a.values[key], key, false)
+			}
+		}
+
+		// compare atoms
+		oldAtom, ok1 := a.atoms[i]
+		newAtom, ok2 := b.atoms[j]
+		if (ok1 || ok2) && valueKey(oldAtom.Name, oldAtom.Text, oldAtom.Payload) != valueKey(newAtom.Name, newAtom.Text, newAtom.Payload) {
+			change.Atoms = append(change.Atoms, AtomChange{
+				Offset: j,
+				Old:    oldAtom,
+				New:    newAtom,
+			})
+		}
+
+		// advance
+		i++
+		j++
+	}
+
+	// sort markup changes
+	sort.SliceStable(change.Markups, func(i, j int) bool {
+		return change.Markups[i].Start < change.Markups[j].Start
+	})
+
+	return change
+}
+
+func (c *ContentChange) addMarkup(open map[string]int, offset int, markup Markup, key string, added bool) {
+	// get run key
+	if added {
+		key = "+" + key
+	} else {
+		key = "-" + key
+	}
+
+	// extend existing change
+	if index, ok := open[key]; ok && c.Markups[index].End == offset {
+		c.Markups[index].End = offset + 1
+		return
+	}
+
+	// add change
+	c.Markups = append(c.Markups, MarkupChange{
+		Start:  offset,
+		End:    offset + 1,
+		Markup: markup,
+		Added:  added,
+	})
+	open[key] = len(c.Markups) - 1
+}
+
+func (c ContentChange) empty() bool {
+	return len(c.Text) == 0 && len(c.Markups) == 0 && len(c.Atoms) == 0
+}
+
+type content struct {
+	text    []rune
+	markups [][]string
+	values  map[string]Markup
+	atoms   map[int]Atom
+}
+
+func contentOf(markers []Marker) content {
+	// get active markups
+	active := activeMarkups(markers)
+
+	// prepare content
+	c := content{
+		values: map[string]Markup{},
+		atoms:  map[int]Atom{},
+	}
+
+	// add markers
+	for i, marker := range markers {
+		// get text
+		text := []rune(marker.Text)
+		if marker.Type == AtomMarker {
+			text = []rune{AtomPlaceholder}
+			if marker.Atom != nil {
+				c.atoms[len(c.text)] = *marker.Atom
+			} else {
+				c.atoms[len(c.text)] = Atom{}
+			}
+		}
+
+		// get markup keys
+		var keys []string
+		for _, markup := range active[i] {
+			if markup == nil {
+				continue
+			}
+			key := markupKey(*markup)
+			if !contains(keys, key) {
+				keys = append(keys, key)
+				c.values[key] = *markup
+			}
+		}
+		sort.Strings(keys)
+
+		// add characters
+		for range text {
+			c.markups = append(c.markups, keys)
+		}
+		c.text = append(c.text, text...)
+	}
+
+	return c
+}
+
+func (c content) key() interface{} {
+	// collect atoms in order
+	offsets := make([]int, 0, len(c.atoms))
+	for offset := range c.atoms {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	atoms := make([]interface{}, 0, len(offsets))
+	for _, offset := range offsets {
+		atom := c.atoms[offset]
+		atoms = append(atoms, []interface{}{offset, atom.Name, atom.Text, atom.Payload})
+	}
+
+	return []interface{}{string(c.text), c.markups, atoms}
+}
+
+func sectionKey(section Section) string {
+	// prepare key
+	key := []interface{}{section.Type, section.Tag, section.Attributes, section.Source}
+
+	// add content
+	switch section.Type {
+	case MarkupSection:
+		key = append(key, contentOf(section.Markers).key())
+	case ListSection:
+		for _, item := range section.Items {
+			key = append(key, contentOf(item).key())
+		}
+	case CardSection:
+		if section.Card != nil {
+			key = append(key, section.Card.Name, section.Card.Payload)
+		}
+	}
+
+	return valueKey(key...)
+}
+
+func sectionKeys(sections []Section) []string {
+	// compute keys
+	keys := make([]string, len(sections))
+	for i, section := range sections {
+		keys[i] = sectionKey(section)
+	}
+
+	return keys
+}
+
+func itemKeys(items [][]Marker) []string {
+	// compute keys
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = valueKey(contentOf(item).key())
+	}
+
+	return keys
+}
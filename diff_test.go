@@ -0,0 +1,150 @@
+package mobiledoc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := NewBuilder().
+		Heading(1).Text("Title").
+		Paragraph().Text("Hello ").Text("bold", Bold).Text(" world").
+		Paragraph().Text("Removed").
+		List("ul", "one", "two", "three").
+		Card("card", Map{"foo": "bar"}).
+		Paragraph().Text("Hi ").Atom("mention", "@a", Map{"id": 1}).
+		Build()
+	require.NoError(t, err)
+
+	new, err := NewBuilder().
+		Heading(1).Text("Title").
+		Paragraph().Text("Hello ").Text("bold", Italic).Text(" big world").
+		List("ul", "one", "2", "three", "four").
+		Card("card", Map{"foo": "baz"}).
+		Paragraph().Text("Hi ").Atom("mention", "@b", Map{"id": 2}).
+		Image("https://example.com/foo.png").
+		Build()
+	require.NoError(t, err)
+
+	changes := Diff(old, new)
+	assert.Equal(t, []SectionChange{
+		{
+			Type:     ChangeModified,
+			OldIndex: 1,
+			NewIndex: 1,
+			Old:      &old.Sections[1],
+			New:      &new.Sections[1],
+			Content: &ContentChange{
+				Text: []TextChange{
+					{OldOffset: 11, NewOffset: 11, Inserted: "big "},
+				},
+				Markups: []MarkupChange{
+					{Start: 6, End: 10, Markup: Italic, Added: true},
+					{Start: 6, End: 10, Markup: Bold, Added: false},
+				},
+			},
+		},
+		{
+			Type:     ChangeRemoved,
+			OldIndex: 2,
+			NewIndex: -1,
+			Old:      &old.Sections[2],
+		},
+		{
+			Type:     ChangeModified,
+			OldIndex: 3,
+			NewIndex: 2,
+			Old:      &old.Sections[3],
+			New:      &new.Sections[2],
+			Items: []ItemChange{
+				{
+					Type:     ChangeModified,
+					OldIndex: 1,
+					NewIndex: 1,
+					Content: &ContentChange{
+						Text: []TextChange{
+							{OldOffset: 0, NewOffset: 0, Removed: "two", Inserted: "2"},
+						},
+					},
+				},
+				{
+					Type:     ChangeInserted,
+					OldIndex: -1,
+					NewIndex: 3,
+				},
+			},
+		},
+		{
+			Type:     ChangeModified,
+			OldIndex: 4,
+			NewIndex: 3,
+			Old:      &old.Sections[4],
+			New:      &new.Sections[3],
+		},
+		{
+			Type:     ChangeModified,
+			OldIndex: 5,
+			NewIndex: 4,
+			Old:      &old.Sections[5],
+			New:      &new.Sections[4],
+			Content: &ContentChange{
+				Atoms: []AtomChange{
+					{
+						Offset: 3,
+						Old:    Atom{Name: "mention", Text: "@a", Payload: Map{"id": 1}},
+						New:    Atom{Name: "mention", Text: "@b", Payload: Map{"id": 2}},
+					},
+				},
+			},
+		},
+		{
+			Type:     ChangeInserted,
+			OldIndex: -1,
+			NewIndex: 5,
+			New:      &new.Sections[5],
+		},
+	}, changes)
+
+	assert.Empty(t, Diff(old, old.Clone()))
+}
+
+func TestDiffText(t *testing.T) {
+	old, err := NewBuilder().Paragraph().Text("The quick brown fox").Build()
+	require.NoError(t, err)
+
+	new, err := NewBuilder().Paragraph().Text("A quick red fox!").Build()
+	require.NoError(t, err)
+
+	changes := Diff(old, new)
+	require.Len(t, changes, 1)
+	assert.Equal(t, &ContentChange{
+		Text: []TextChange{
+			{OldOffset: 0, NewOffset: 0, Removed: "The", Inserted: "A"},
+			{OldOffset: 10, NewOffset: 8, Removed: "b", Inserted: ""},
+			{OldOffset: 12, NewOffset: 9, Removed: "own", Inserted: "ed"},
+			{OldOffset: 19, NewOffset: 15, Removed: "", Inserted: "!"},
+		},
+	}, changes[0].Content)
+}
+
+func TestDiffLargeText(t *testing.T) {
+	text := strings.Repeat("lorem ipsum dolor sit amet ", 400)
+
+	old, err := NewBuilder().Paragraph().Text("A" + text + "Z").Build()
+	require.NoError(t, err)
+
+	new, err := NewBuilder().Paragraph().Text("B" + text + "Y").Build()
+	require.NoError(t, err)
+
+	changes := Diff(old, new)
+	require.Len(t, changes, 1)
+	assert.Equal(t, &ContentChange{
+		Text: []TextChange{
+			{OldOffset: 0, NewOffset: 0, Removed: "A", Inserted: "B"},
+			{OldOffset: len(text) + 1, NewOffset: len(text) + 1, Removed: "Z", Inserted: "Y"},
+		},
+	}, changes[0].Content)
+}
@@ -0,0 +1,496 @@
+package mobiledoc
+
+import "unicode/utf8"
+
+// Position identifies a character offset in a markup section or list item.
+// Offsets are measured in runes and atoms count as a single character.
+type Position struct {
+	// The section index.
+	Section int
+
+	// The item index, only used for list sections.
+	Item int
+
+	// The character offset.
+	Offset int
+}
+
+// Before returns whether the position is located before the other position.
+func (p Position) Before(other Position) bool {
+	if p.Section != other.Section {
+		return p.Section < other.Section
+	} else if p.Item != other.Item {
+		return p.Item < other.Item
+	}
+	return p.Offset < other.Offset
+}
+
+// InsertText will insert the text at the specified position. The text inherits
+// the markups of the preceding character, or of the following character when
+// inserted at the beginning.
+func (d *Document) InsertText(pos Position, text string) error {
+	// get ranges
+	r, err := d.ranges(pos)
+	if err != nil {
+		return err
+	}
+
+	// insert text
+	runes := []rune(r.Text)
+	n := len([]rune(text))
+	r.Text = string(runes[:pos.Offset]) + text + string(runes[pos.Offset:])
+
+	// shift markup ranges
+	for i, mr := range r.Markups {
+		if mr.Start > pos.Offset || (mr.Start == pos.Offset && pos.Offset > 0) {
+			r.Markups[i].Start += n
+		}
+		if mr.End >= pos.Offset {
+			r.Markups[i].End += n
+		}
+	}
+
+	// shift atoms
+	for i, atom := range r.Atoms {
+		if atom.Offset >= pos.Offset {
+			r.Atoms[i].Offset += n
+		}
+	}
+
+	// set markers
+	d.setMarkers(pos, FromRanges(r))
+
+	return nil
+}
+
+// DeleteRange will delete the content between the two positions. If the
+// positions are located in different sections or items, the remaining content
+// of the last one is joined into the first one and everything in between is
+// removed.
+func (d *Document) DeleteRange(from, to Position) error {
+	// check order
+	if to.Before(from) {
+		from, to = to, from
+	}
+
+	// get ranges
+	head, err := d.ranges(from)
+	if err != nil {
+		return err
+	}
+	tail, err := d.ranges(to)
+	if err != nil {
+		return err
+	}
+
+	// join remaining content
+	joined := concatRanges(sliceRanges(head, 0, from.Offset), sliceRanges(tail, to.Offset, runeCount(tail.Text)))
+
+	// set markers
+	d.setMarkers(from, FromRanges(joined))
+
+	// handle same section
+	if from.Section == to.Section {
+		if from.Item != to.Item {
+			section := &d.Sections[from.Section]
+			section.Items = append(section.Items[:from.Item+1], section.Items[to.Item+1:]...)
+		}
+		return nil
+	}
+
+	// remove items of last section
+	last := d.Sections[to.Section]
+	remove := to.Section + 1
+	if last.Type == ListSection && to.Item+1 < len(last.Items) {
+		d.Sections[to.Section].Items = last.Items[to.Item+1:]
+		remove = to.Section
+	}
+
+	// remove items of first section
+	if first := &d.Sections[from.Section]; first.Type == ListSection {
+		first.Items = first.Items[:from.Item+1]
+	}
+
+	// remove sections in between
+	d.Sections = append(d.Sections[:from.Section+1], d.Sections[remove:]...)
+
+	return nil
+}
+
+// SplitSection will split the markup section or list item at the specified
+// position. Markup sections are split into two sections with the same tag and
+// attributes while list items are split into two items.
+func (d *Document) SplitSection(pos Position) error {
+	// get ranges
+	r, err := d.ranges(pos)
+	if err != nil {
+		return err
+	}
+
+	// split ranges
+	n := runeCount(r.Text)
+	head := FromRanges(sliceRanges(r, 0, pos.Offset))
+	tail := FromRanges(sliceRanges(r, pos.Offset, n))
+
+	// get section
+	section := d.Sections[pos.Section]
+
+	// split list item
+	if section.Type == ListSection {
+		items := make([][]Marker, 0, len(section.Items)+1)
+		items = append(items, section.Items[:pos.Item]...)
+		items = append(items, head, tail)
+		items = append(items, section.Items[pos.Item+1:]...)
+		d.Sections[pos.Section].Items = items
+		return nil
+	}
+
+	// split markup section
+	second := section
+	second.Attributes = cloneMap(section.Attributes)
+	second.Markers = tail
+	d.Sections[pos.Section].Markers = head
+	d.Sections = append(d.Sections[:pos.Section+1], append([]Section{second}, d.Sections[pos.Section+1:]...)...)
+
+	return nil
+}
+
+// JoinSections will join the section at the specified index with the following
+// section. Markup sections are joined by appending the content of the second
+// section to the first one. List sections with the same tag are joined by
+// appending the items of the second section.
+func (d *Document) JoinSections(index int) error {
+	// check index
+	if index < 0 || index+1 >= len(d.Sections) {
+		return ErrInvalidPosition
+	}
+
+	// get sections
+	first := d.Sections[index]
+	second := d.Sections[index+1]
+
+	// join sections
+	switch {
+	case first.Type == MarkupSection && second.Type == MarkupSection:
+		a := ToRanges(first.Markers)
+		b := ToRanges(second.Markers)
+		d.Sections[index].Markers = FromRanges(concatRanges(a, b))
+	case first.Type == ListSection && second.Type == ListSection && first.Tag == second.Tag:
+		d.Sections[index].Items = append(first.Items[:len(first.Items):len(first.Items)], second.Items...)
+	default:
+		return ErrInvalidJoin
+	}
+
+	// remove second section
+	d.Sections = append(d.Sections[:index+1], d.Sections[index+2:]...)
+
+	return nil
+}
+
+// ToggleMarkup will toggle the markup for the content between the two
+// positions. If all content is already marked up with a markup of the same tag,
+// the markups with that tag are removed from the content. Otherwise, the markup
+// is applied to the content and added to the document if missing.
+func (d *Document) ToggleMarkup(from, to Position, markup Markup) error {
+	// check order
+	if to.Before(from) {
+		from, to = to, from
+	}
+
+	// check positions
+	_, err := d.ranges(from)
+	if err != nil {
+		return err
+	}
+	_, err = d.ranges(to)
+	if err != nil {
+		return err
+	}
+
+	// collect segments
+	segments := d.segments(from, to)
+
+	// check if all content is marked up
+	marked := true
+	for _, segment := range segments {
+		if !segment.covered(markup.Tag) {
+			marked = false
+			break
+		}
+	}
+
+	// add markup and collect segments again as markups may have been
+	// reallocated
+	var ptr *Markup
+	if !marked {
+		ptr = d.addMarkup(markup)
+		segments = d.segments(from, to)
+	}
+
+	// update segments
+	for _, segment := range segments {
+		if segment.start == segment.end {
+			continue
+		}
+		if marked {
+			segment.ranges.Markups = removeMarkupRange(segment.ranges.Markups, markup.Tag, segment.start, segment.end)
+		} else {
+			segment.ranges.Markups = append(segment.ranges.Markups, MarkupRange{
+				Start:  segment.start,
+				End:    segment.end,
+				Markup: ptr,
+			})
+		}
+		d.setMarkers(segment.pos, FromRanges(segment.ranges))
+	}
+
+	return nil
+}
+
+type editSegment struct {
+	pos    Position
+	ranges Ranges
+	start  int
+	end    int
+}
+
+func (s editSegment) covered(tag string) bool {
+	// check each character
+	for offset := s.start; offset < s.end; offset++ {
+		found := false
+		for _, r := range s.ranges.Markups {
+			if r.Markup.Tag == tag && r.Start <= offset && r.End > offset {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d *Document) segments(from, to Position) []editSegment {
+	// collect segments of all markup sections and list items in range
+	var segments []editSegment
+	for i := from.Section; i <= to.Section; i++ {
+		section := d.Sections[i]
+		switch section.Type {
+		case MarkupSection:
+			segments = append(segments, d.segment(Position{Section: i}, from, to))
+		case ListSection:
+			for j := range section.Items {
+				if i == from.Section && j < from.Item || i == to.Section && j > to.Item {
+					continue
+				}
+				segments = append(segments, d.segment(Position{Section: i, Item: j}, from, to))
+			}
+		}
+	}
+
+	return segments
+}
+
+func (d *Document) segment(pos, from, to Position) editSegment {
+	// get ranges
+	r, _ := d.ranges(pos)
+
+	// prepare segment
+	segment := editSegment{
+		pos:    pos,
+		ranges: r,
+		end:    runeCount(r.Text),
+	}
+
+	// limit range
+	if pos.Section == from.Section && pos.Item == from.Item {
+		segment.start = from.Offset
+	}
+	if pos.Section == to.Section && pos.Item == to.Item {
+		segment.end = to.Offset
+	}
+
+	return segment
+}
+
+func (d *Document) ranges(pos Position) (Ranges, error) {
+	// check section
+	if pos.Section < 0 || pos.Section >= len(d.Sections) {
+		return Ranges{}, ErrInvalidPosition
+	}
+
+	// get markers
+	var markers []Marker
+	switch section := d.Sections[pos.Section]; section.Type {
+	case MarkupSection:
+		if pos.Item != 0 {
+			return Ranges{}, ErrInvalidPosition
+		}
+		markers = section.Markers
+	case ListSection:
+		if pos.Item < 0 || pos.Item >= len(section.Items) {
+			return Ranges{}, ErrInvalidPosition
+		}
+		markers = section.Items[pos.Item]
+	default:
+		return Ranges{}, ErrInvalidPosition
+	}
+
+	// get ranges
+	r := ToRanges(markers)
+
+	// check offset
+	if pos.Offset < 0 || pos.Offset > runeCount(r.Text) {
+		return Ranges{}, ErrInvalidPosition
+	}
+
+	return r, nil
+}
+
+func (d *Document) setMarkers(pos Position, markers []Marker) {
+	// set markers
+	section := &d.Sections[pos.Section]
+	if section.Type == ListSection {
+		section.Items[pos.Item] = markers
+	} else {
+		section.Markers = markers
+	}
+}
+
+func (d *Document) addMarkup(markup Markup) *Markup {
+	// find existing markup
+	for i, m := range d.Markups {
+		if m.Tag == markup.Tag && equalAttributes(m.Attributes, markup.Attributes) {
+			return &d.Markups[i]
+		}
+	}
+
+	// add markup
+	old := d.Markups
+	d.Markups = append(d.Markups, cloneMarkup(markup))
+
+	// rewire references if the markups have been reallocated
+	if len(old) > 0 && &old[0] != &d.Markups[0] {
+		rewire := func(markers []Marker) {
+			for i := range markers {
+				for j, m := range markers[i].OpenMarkups {
+					for k := range old {
+						if m == &old[k] {
+							markers[i].OpenMarkups[j] = &d.Markups[k]
+							break
+						}
+					}
+				}
+			}
+		}
+		for _, section := range d.Sections {
+			rewire(section.Markers)
+			for _, item := range section.Items {
+				rewire(item)
+			}
+		}
+	}
+
+	return &d.Markups[len(d.Markups)-1]
+}
+
+func sliceRanges(r Ranges, start, end int) Ranges {
+	// prepare result
+	runes := []rune(r.Text)
+	result := Ranges{
+		Text: string(runes[start:end]),
+	}
+
+	// slice markup ranges
+	for _, mr := range r.Markups {
+		if mr.End > start && mr.Start < end {
+			result.Markups = append(result.Markups, MarkupRange{
+				Start:  maxInt(mr.Start, start) - start,
+				End:    minInt(mr.End, end) - start,
+				Markup: mr.Markup,
+			})
+		}
+	}
+
+	// slice atoms
+	for _, atom := range r.Atoms {
+		if atom.Offset >= start && atom.Offset < end {
+			result.Atoms = append(result.Atoms, AtomPosition{
+				Offset: atom.Offset - start,
+				Atom:   atom.Atom,
+			})
+		}
+	}
+
+	return result
+}
+
+func concatRanges(a, b Ranges) Ranges {
+	// get offset
+	offset := runeCount(a.Text)
+
+	// prepare result
+	result := Ranges{
+		Text:    a.Text + b.Text,
+		Markups: append([]MarkupRange{}, a.Markups...),
+		Atoms:   append([]AtomPosition{}, a.Atoms...),
+	}
+
+	// append shifted ranges and atoms
+	for _, mr := range b.Markups {
+		result.Markups = append(result.Markups, MarkupRange{
+			Start:  mr.Start + offset,
+			End:    mr.End + offset,
+			Markup: mr.Markup,
+		})
+	}
+	for _, atom := range b.Atoms {
+		result.Atoms = append(result.Atoms, AtomPosition{
+			Offset: atom.Offset + offset,
+			Atom:   atom.Atom,
+		})
+	}
+
+	return result
+}
+
+func removeMarkupRange(list []MarkupRange, tag string, start, end int) []MarkupRange {
+	// prepare result
+	result := make([]MarkupRange, 0, len(list))
+
+	// remove range from markups with tag
+	for _, mr := range list {
+		if mr.Markup.Tag != tag || mr.End <= start || mr.Start >= end {
+			result = append(result, mr)
+			continue
+		}
+		if mr.Start < start {
+			result = append(result, MarkupRange{Start: mr.Start, End: start, Markup: mr.Markup})
+		}
+		if mr.End > end {
+			result = append(result, MarkupRange{Start: end, End: mr.End, Markup: mr.Markup})
+		}
+	}
+
+	return result
+}
+
+func runeCount(str string) int {
+	return utf8.RuneCountInString(str)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,140 @@
+package mobiledoc
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func editDoc(t *testing.T) Document {
+	doc, err := NewBuilder().
+		Paragraph().Text("Hello ").Text("bold", Bold).Text(" world").
+		List("ul", "one", "two", "three").
+		Card("card", Map{}).
+		Heading(2).Text("Ti").Atom("atom", "@a", Map{}).Text("tle", Italic).
+		Build()
+	require.NoError(t, err)
+	return doc
+}
+
+func editHTML(t *testing.T, doc Document) string {
+	assert.NoError(t, NewFormatValidator().Validate(doc))
+
+	r := NewHTMLRenderer()
+	r.Atoms["atom"] = func(w *bufio.Writer, text string, payload Map) error {
+		_, err := w.WriteString(text)
+		return err
+	}
+	r.Cards["card"] = func(w *bufio.Writer, payload Map) error {
+		_, err := w.WriteString("<hr>")
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	err := r.Render(buf, doc)
+	assert.NoError(t, err)
+
+	return buf.String()
+}
+
+func TestDocumentInsertText(t *testing.T) {
+	doc := editDoc(t)
+
+	err := doc.InsertText(Position{Section: 0, Offset: 10}, "er")
+	assert.NoError(t, err)
+	err = doc.InsertText(Position{Section: 0, Offset: 6}, "big ")
+	assert.NoError(t, err)
+	err = doc.InsertText(Position{Section: 1, Item: 1, Offset: 0}, "number ")
+	assert.NoError(t, err)
+	err = doc.InsertText(Position{Section: 3, Offset: 3}, "!")
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hello big <b>bolder</b> world</p><ul><li>one</li><li>number two</li><li>three</li></ul><hr><h2>Ti@a!<i>tle</i></h2>`, editHTML(t, doc))
+
+	err = doc.InsertText(Position{Section: 0, Offset: 100}, "x")
+	assert.ErrorIs(t, err, ErrInvalidPosition)
+	err = doc.InsertText(Position{Section: 2}, "x")
+	assert.ErrorIs(t, err, ErrInvalidPosition)
+	err = doc.InsertText(Position{Section: 1, Item: 3}, "x")
+	assert.ErrorIs(t, err, ErrInvalidPosition)
+}
+
+func TestDocumentDeleteRange(t *testing.T) {
+	doc := editDoc(t)
+	err := doc.DeleteRange(Position{Section: 0, Offset: 8}, Position{Section: 0, Offset: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hell<b>ld</b> world</p><ul><li>one</li><li>two</li><li>three</li></ul><hr><h2>Ti@a<i>tle</i></h2>`, editHTML(t, doc))
+
+	doc = editDoc(t)
+	err = doc.DeleteRange(Position{Section: 1, Item: 0, Offset: 1}, Position{Section: 1, Item: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hello <b>bold</b> world</p><ul><li>oree</li></ul><hr><h2>Ti@a<i>tle</i></h2>`, editHTML(t, doc))
+
+	doc = editDoc(t)
+	err = doc.DeleteRange(Position{Section: 0, Offset: 8}, Position{Section: 1, Item: 1, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hello <b>bo</b>wo</p><ul><li>three</li></ul><hr><h2>Ti@a<i>tle</i></h2>`, editHTML(t, doc))
+
+	doc = editDoc(t)
+	err = doc.DeleteRange(Position{Section: 1, Item: 2, Offset: 5}, Position{Section: 3, Offset: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hello <b>bold</b> world</p><ul><li>one</li><li>two</li><li>three<i>tle</i></li></ul>`, editHTML(t, doc))
+
+	doc = editDoc(t)
+	err = doc.DeleteRange(Position{Section: 0, Offset: 0}, Position{Section: 2})
+	assert.ErrorIs(t, err, ErrInvalidPosition)
+}
+
+func TestDocumentSplitSection(t *testing.T) {
+	doc := editDoc(t)
+
+	err := doc.SplitSection(Position{Section: 0, Offset: 8})
+	assert.NoError(t, err)
+	err = doc.SplitSection(Position{Section: 2, Item: 1, Offset: 3})
+	assert.NoError(t, err)
+	err = doc.SplitSection(Position{Section: 4, Offset: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hello <b>bo</b></p><p><b>ld</b> world</p><ul><li>one</li><li>two</li><li></li><li>three</li></ul><hr><h2></h2><h2>Ti@a<i>tle</i></h2>`, editHTML(t, doc))
+}
+
+func TestDocumentJoinSections(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("foo", Bold).
+		Heading(1).Text("bar", Bold).
+		List("ul", "a").
+		List("ul", "b").
+		List("ol", "c").
+		Build()
+	require.NoError(t, err)
+
+	err = doc.JoinSections(0)
+	assert.NoError(t, err)
+	err = doc.JoinSections(1)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p><b>foobar</b></p><ul><li>a</li><li>b</li></ul><ol><li>c</li></ol>`, editHTML(t, doc))
+
+	err = doc.JoinSections(0)
+	assert.ErrorIs(t, err, ErrInvalidJoin)
+	err = doc.JoinSections(1)
+	assert.ErrorIs(t, err, ErrInvalidJoin)
+	err = doc.JoinSections(2)
+	assert.ErrorIs(t, err, ErrInvalidPosition)
+}
+
+func TestDocumentToggleMarkup(t *testing.T) {
+	doc := editDoc(t)
+
+	err := doc.ToggleMarkup(Position{Section: 0, Offset: 3}, Position{Section: 1, Item: 1, Offset: 2}, Underline)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hel<u>lo <b>bold</b> world</u></p><ul><li><u>one</u></li><li><u>tw</u>o</li><li>three</li></ul><hr><h2>Ti@a<i>tle</i></h2>`, editHTML(t, doc))
+
+	err = doc.ToggleMarkup(Position{Section: 0, Offset: 8}, Position{Section: 1, Item: 0, Offset: 3}, Underline)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hel<u>lo <b>bo</b></u><b>ld</b> world</p><ul><li>one</li><li><u>tw</u>o</li><li>three</li></ul><hr><h2>Ti@a<i>tle</i></h2>`, editHTML(t, doc))
+
+	err = doc.ToggleMarkup(Position{Section: 3, Offset: 0}, Position{Section: 3, Offset: 6}, Link("https://example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>Hel<u>lo <b>bo</b></u><b>ld</b> world</p><ul><li>one</li><li><u>tw</u>o</li><li>three</li></ul><hr><h2><a href="https://example.com">Ti@a<i>tle</i></a></h2>`, editHTML(t, doc))
+}
@@ -0,0 +1,65 @@
+package mobiledoc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// EqualOptions defines options for comparing documents.
+type EqualOptions struct {
+	// IgnoreSplits will ignore how text is split into markers. Empty text
+	// markers are ignored and adjacent text markers with the same markups are
+	// compared as a single marker.
+	IgnoreSplits bool
+}
+
+// Equal returns whether the two documents have the same content. Markup, atom
+// and card references are compared by value, unused and duplicate definitions
+// are ignored as well as the nesting of markups.
+func Equal(a, b Document) bool {
+	return EqualWith(a, b, EqualOptions{})
+}
+
+// EqualWith returns whether the two documents have the same content using the
+// specified options.
+func EqualWith(a, b Document, opts EqualOptions) bool {
+	// get canonical forms
+	ca, err := canonicalJSON(a, opts.IgnoreSplits)
+	if err != nil {
+		return false
+	}
+	cb, err := canonicalJSON(b, opts.IgnoreSplits)
+	if err != nil {
+		return false
+	}
+
+	return string(ca) == string(cb)
+}
+
+// Hash returns a stable hex encoded SHA-256 digest of the document content
+// computed from its canonical compiled form. Documents that are Equal have the
+// same hash.
+func (d Document) Hash() (string, error) {
+	// get canonical form
+	buf, err := canonicalJSON(d, false)
+	if err != nil {
+		return "", err
+	}
+
+	// compute hash
+	sum := sha256.Sum256(buf)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func canonicalJSON(doc Document, merge bool) ([]byte, error) {
+	// compile canonical document
+	compiler := compiler{doc: canonicalize(doc, merge)}
+	result := compiler.compile()
+	if len(compiler.errors) > 0 {
+		return nil, compiler.errors[0]
+	}
+
+	return json.Marshal(result)
+}
@@ -0,0 +1,115 @@
+package mobiledoc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(sampleDoc(), sampleDoc()))
+	assert.True(t, Equal(sampleDoc(), sampleDoc().Clone()))
+
+	var doc Document
+	err := json.Unmarshal([]byte(sampleJSON), &doc)
+	assert.NoError(t, err)
+	assert.True(t, Equal(sampleDoc(), doc))
+
+	doc.Sections[1].Markers[0].Text = "bar"
+	assert.False(t, Equal(sampleDoc(), doc))
+
+	doc = sampleDoc()
+	doc.Cards[0].Payload["foo"] = 7
+	assert.False(t, Equal(sampleDoc(), doc))
+
+	doc = sampleDoc()
+	doc.Markups[2].Attributes["href"] = "https://example.org"
+	assert.False(t, Equal(sampleDoc(), doc))
+}
+
+func TestEqualDefinitions(t *testing.T) {
+	a := Document{
+		Version: Version,
+		Markups: []Markup{{Tag: "b"}, {Tag: "b"}, {Tag: "i"}},
+		Atoms:   []Atom{{Name: "foo", Payload: Map{}}},
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{
+				{Type: TextMarker, Text: "a"},
+			}},
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{
+				{Type: TextMarker, Text: "b"},
+			}},
+		},
+	}
+	a.Sections[0].Markers[0].OpenMarkups = []*Markup{&a.Markups[0]}
+	a.Sections[0].Markers[0].ClosedMarkups = 1
+	a.Sections[1].Markers[0].OpenMarkups = []*Markup{&a.Markups[1]}
+	a.Sections[1].Markers[0].ClosedMarkups = 1
+
+	b, err := NewBuilder().
+		Paragraph().Text("a", Bold).
+		Paragraph().Text("b", Bold).
+		Build()
+	assert.NoError(t, err)
+
+	assert.True(t, Equal(a, b))
+}
+
+func TestEqualNesting(t *testing.T) {
+	a := Document{
+		Version: Version,
+		Markups: []Markup{{Tag: "b"}, {Tag: "i"}},
+	}
+	a.Sections = []Section{
+		{Type: MarkupSection, Tag: "p", Markers: []Marker{
+			{Type: TextMarker, OpenMarkups: []*Markup{&a.Markups[0], &a.Markups[1]}, ClosedMarkups: 2, Text: "a"},
+		}},
+	}
+
+	b := Document{
+		Version: Version,
+		Markups: []Markup{{Tag: "i"}, {Tag: "b"}},
+	}
+	b.Sections = []Section{
+		{Type: MarkupSection, Tag: "p", Markers: []Marker{
+			{Type: TextMarker, OpenMarkups: []*Markup{&b.Markups[0], &b.Markups[1]}, ClosedMarkups: 2, Text: "a"},
+		}},
+	}
+
+	assert.True(t, Equal(a, b))
+}
+
+func TestEqualSplits(t *testing.T) {
+	a, err := NewBuilder().
+		Paragraph().Text("foo", Bold).Text("bar", Bold).Text("").Text("baz").
+		Build()
+	assert.NoError(t, err)
+
+	b, err := NewBuilder().
+		Paragraph().Text("foobar", Bold).Text("baz").
+		Build()
+	assert.NoError(t, err)
+
+	assert.False(t, Equal(a, b))
+	assert.True(t, EqualWith(a, b, EqualOptions{IgnoreSplits: true}))
+}
+
+func TestHash(t *testing.T) {
+	hash, err := sampleDoc().Hash()
+	assert.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	var doc Document
+	err = json.Unmarshal([]byte(sampleJSON), &doc)
+	assert.NoError(t, err)
+
+	hash2, err := doc.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, hash, hash2)
+
+	doc.Sections[1].Markers[0].Text = "bar"
+	hash3, err := doc.Hash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, hash3)
+}
@@ -0,0 +1,179 @@
+package mobiledoc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The validation error kinds.
+var (
+	ErrInvalidVersion            = errors.New("invalid version")
+	ErrInvalidMarkupTag          = errors.New("invalid markup tag")
+	ErrInvalidMarkupAttributes   = errors.New("invalid markup attributes")
+	ErrInvalidAtomName           = errors.New("invalid atom name")
+	ErrInvalidAtom               = errors.New("invalid atom text or payload")
+	ErrInvalidCardName           = errors.New("invalid card name")
+	ErrInvalidCardPayload        = errors.New("invalid card payload")
+	ErrInvalidSectionType        = errors.New("invalid section type")
+	ErrInvalidMarkupSectionTag   = errors.New("invalid markup section tag")
+	ErrInvalidListSectionTag     = errors.New("invalid list section tag")
+	ErrInvalidSectionAttributes  = errors.New("invalid section attributes")
+	ErrInvalidImageSection       = errors.New("invalid image section")
+	ErrInvalidImageSectionSource = errors.New("invalid image section src")
+	ErrInvalidCardReference      = errors.New("invalid card reference")
+	ErrInvalidMarkerType         = errors.New("invalid marker type")
+	ErrInvalidMarkupReference    = errors.New("invalid markup reference")
+	ErrInvalidAtomReference      = errors.New("invalid atom reference")
+	ErrInvalidClosedMarkups      = errors.New("invalid closed markups")
+)
+
+// ValidationError describes a single problem found by the validator.
+type ValidationError struct {
+	// Path is the location of the problem, e.g. "sections[3].markers[2]".
+	Path string
+
+	// Name is the offending tag or name, if available.
+	Name string
+
+	// Kind is one of the validation error kinds.
+	Kind error
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s: %s %q", e.Path, e.Kind, e.Name)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Kind)
+}
+
+// Unwrap returns the error kind.
+func (e *ValidationError) Unwrap() error {
+	return e.Kind
+}
+
+// ValidationErrors is a list of validation errors.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	list := make([]string, 0, len(e))
+	for _, err := range e {
+		list = append(list, err.Error())
+	}
+	return strings.Join(list, "; ")
+}
+
+// Is returns whether one of the errors matches the target.
+func (e ValidationErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// The parse error kinds. Parse errors may also use ErrInvalidVersion,
+// ErrInvalidSectionType, ErrInvalidMarkerType and ErrInvalidClosedMarkups.
+var (
+	ErrInvalidValue  = errors.New("invalid value")
+	ErrInvalidLength = errors.New("invalid length")
+	ErrInvalidIndex  = errors.New("invalid index")
+)
+
+// ParseError describes a problem found while parsing a raw document.
+type ParseError struct {
+	// Pointer is the JSON pointer of the offending value, e.g.
+	// "/sections/4/2/1/1".
+	Pointer string
+
+	// Expected describes the expected value.
+	Expected string
+
+	// Actual describes the found value.
+	Actual string
+
+	// Kind is one of the parse error kinds.
+	Kind error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at %q: expected %s, got %s", e.Kind, e.Pointer, e.Expected, e.Actual)
+}
+
+// Unwrap returns the error kind.
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
+
+func parseError(kind error, expected, actual string, segments ...interface{}) *ParseError {
+	return &ParseError{
+		Pointer:  pointer(segments),
+		Expected: expected,
+		Actual:   actual,
+		Kind:     kind,
+	}
+}
+
+func prefixError(err error, segments ...interface{}) error {
+	// prepend segments to parse errors
+	if pe, ok := err.(*ParseError); ok {
+		pe.Pointer = pointer(segments) + pe.Pointer
+	}
+
+	return err
+}
+
+func pointer(segments []interface{}) string {
+	// build pointer
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString("/")
+		b.WriteString(pointerEscaper.Replace(fmt.Sprint(segment)))
+	}
+
+	return b.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func describe(v interface{}) string {
+	// check nil
+	if v == nil {
+		return "null"
+	}
+
+	// check known kinds
+	if _, ok := v.(string); ok {
+		return "string"
+	} else if _, ok := v.(bool); ok {
+		return "boolean"
+	} else if _, ok := toInt(v); ok {
+		return "number"
+	} else if _, ok := toList(v); ok {
+		return "list"
+	} else if _, ok := toMap(v); ok {
+		return "map"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func describeLength(list List) string {
+	if len(list) == 1 {
+		return "1 element"
+	}
+	return fmt.Sprintf("%d elements", len(list))
+}
+
+// The editing errors.
+var (
+	ErrInvalidPosition = errors.New("invalid position")
+	ErrInvalidJoin     = errors.New("invalid join")
+)
+
+// ErrInvalidPatch is returned if a patch cannot be applied to a document.
+var ErrInvalidPatch = errors.New("invalid patch")
@@ -0,0 +1,179 @@
+package mobiledoc
+
+import "unicode"
+
+// ExcerptOptions defines the limits of an excerpt. Zero values disable the
+// respective limit.
+type ExcerptOptions struct {
+	// The maximum number of characters. Atoms count as a single character.
+	MaxChars int
+
+	// The maximum number of words. Atoms count as part of a word.
+	MaxWords int
+
+	// The maximum number of sections.
+	MaxSections int
+
+	// Whether card sections should be omitted.
+	SkipCards bool
+
+	// The text appended to the last markup section or list item if the
+	// document has been truncated.
+	Ellipsis string
+}
+
+// Excerpt returns a copy of the document that is truncated according to the
+// provided options. Text is truncated at word boundaries and atoms are never
+// split. Only if the first word exceeds the character limit it is cut within
+// the word. Sections and list items without remaining content are omitted. The
+// open and closed markups of truncated markers are rebalanced and definitions
+// that are no longer referenced are removed.
+func Excerpt(doc Document, opts ExcerptOptions) Document {
+	// prepare excerpter
+	e := excerpter{opts: opts}
+
+	// prepare sections
+	var sections []Section
+
+	for _, section := range doc.Sections {
+		// skip cards
+		if section.Type == CardSection && opts.SkipCards {
+			continue
+		}
+
+		// check sections and content
+		if (opts.MaxSections > 0 && len(sections) >= opts.MaxSections) || e.exhausted() {
+			e.truncated = true
+			break
+		}
+
+		// truncate content
+		keep := true
+		switch section.Type {
+		case MarkupSection:
+			section.Markers, keep = e.markers(section.Markers)
+		case ListSection:
+			var items [][]Marker
+			for _, item := range section.Items {
+				if e.exhausted() {
+					e.truncated = true
+					break
+				}
+				markers, ok := e.markers(item)
+				if ok {
+					items = append(items, markers)
+				}
+				if e.truncated {
+					break
+				}
+			}
+			keep = len(items) > 0 || len(section.Items) == 0
+			section.Items = items
+		}
+
+		// add section
+		if keep {
+			sections = append(sections, section)
+		}
+		if e.truncated {
+			break
+		}
+	}
+
+	// append ellipsis to the last markers
+	if e.truncated && opts.Ellipsis != "" && len(sections) > 0 {
+		last := &sections[len(sections)-1]
+		switch last.Type {
+		case MarkupSection:
+			last.Markers = appendEllipsis(last.Markers, opts.Ellipsis)
+		case ListSection:
+			if len(last.Items) > 0 {
+				item := &last.Items[len(last.Items)-1]
+				*item = appendEllipsis(*item, opts.Ellipsis)
+			}
+		}
+	}
+
+	return extractSections(doc.Version, sections)
+}
+
+type excerpter struct {
+	opts      ExcerptOptions
+	chars     int
+	words     int
+	truncated bool
+}
+
+func (e *excerpter) exhausted() bool {
+	return (e.opts.MaxChars > 0 && e.chars >= e.opts.MaxChars) ||
+		(e.opts.MaxWords > 0 && e.words >= e.opts.MaxWords)
+}
+
+func (e *excerpter) markers(markers []Marker) ([]Marker, bool) {
+	// get emitted characters
+	emitted := e.chars
+
+	// get ranges
+	ranges := ToRanges(markers)
+	runes := []rune(ranges.Text)
+
+	// find cut
+	cut, hard := -1, -1
+	for i, r := range runes {
+		// check characters
+		if e.opts.MaxChars > 0 && e.chars >= e.opts.MaxChars {
+			cut, hard = i, i
+			if i > 0 && !unicode.IsSpace(r) && !unicode.IsSpace(runes[i-1]) {
+				cut = wordStart(runes, i)
+			}
+			break
+		}
+
+		// check words
+		if !unicode.IsSpace(r) && (i == 0 || unicode.IsSpace(runes[i-1])) {
+			if e.opts.MaxWords > 0 && e.words >= e.opts.MaxWords {
+				cut = i
+				break
+			}
+			e.words++
+		}
+
+		e.chars++
+	}
+
+	// return unchanged markers
+	if cut < 0 {
+		return markers, true
+	}
+
+	// trim trailing whitespace
+	for cut > 0 && unicode.IsSpace(runes[cut-1]) {
+		cut--
+	}
+
+	// set flag
+	e.truncated = true
+
+	// cut within the first word if no word boundary fits, otherwise omit the
+	// markers if nothing remains
+	if cut == 0 && hard > 0 && emitted == 0 {
+		cut = hard
+	} else if cut == 0 {
+		return nil, false
+	}
+
+	return FromRanges(sliceRanges(ranges, 0, cut)), true
+}
+
+func wordStart(runes []rune, i int) int {
+	// find start of word
+	for i > 0 && !unicode.IsSpace(runes[i-1]) {
+		i--
+	}
+
+	return i
+}
+
+func appendEllipsis(markers []Marker, ellipsis string) []Marker {
+	return FromRanges(concatRanges(ToRanges(markers), Ranges{Text: ellipsis}))
+}
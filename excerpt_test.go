@@ -0,0 +1,152 @@
+package mobiledoc
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcerpt(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("Hello ").Text("bold world", Bold).Text(" ").Text("again", Italic).
+		Paragraph().Text("Second para").
+		Build()
+	require.NoError(t, err)
+
+	table := []struct {
+		opts ExcerptOptions
+		out  string
+	}{
+		{
+			opts: ExcerptOptions{},
+			out:  `<p>Hello <b>bold world</b> <i>again</i></p><p>Second para</p>`,
+		},
+		{
+			opts: ExcerptOptions{MaxChars: 11, Ellipsis: "…"},
+			out:  `<p>Hello <b>bold</b>…</p>`,
+		},
+		{
+			opts: ExcerptOptions{MaxChars: 14},
+			out:  `<p>Hello <b>bold</b></p>`,
+		},
+		{
+			opts: ExcerptOptions{MaxChars: 3},
+			out:  `<p>Hel</p>`,
+		},
+		{
+			opts: ExcerptOptions{MaxChars: 3, Ellipsis: "…"},
+			out:  `<p>Hel…</p>`,
+		},
+		{
+			opts: ExcerptOptions{MaxChars: 23, Ellipsis: "…"},
+			out:  `<p>Hello <b>bold world</b> <i>again</i>…</p>`,
+		},
+		{
+			opts: ExcerptOptions{MaxChars: 30},
+			out:  `<p>Hello <b>bold world</b> <i>again</i></p><p>Second</p>`,
+		},
+	}
+
+	for _, item := range table {
+		out, err := RenderHTML(Excerpt(doc, item.opts))
+		assert.NoError(t, err)
+		assert.Equal(t, template.HTML(item.out), out)
+	}
+
+	res := Excerpt(doc, ExcerptOptions{MaxChars: 11})
+	assert.Equal(t, []Markup{{Tag: "b"}}, res.Markups)
+	assert.Empty(t, res.Cards)
+	assert.Empty(t, res.Atoms)
+}
+
+func TestExcerptWords(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("Hello ").Text("bold world", Bold).Text(" again").
+		Card("card", Map{}).
+		List("ul", "one two", "three").
+		Heading(2).Text("Title").
+		Build()
+	require.NoError(t, err)
+
+	table := []struct {
+		opts  ExcerptOptions
+		out   string
+		cards int
+	}{
+		{
+			opts: ExcerptOptions{MaxWords: 2, Ellipsis: "..."},
+			out:  `<p>Hello <b>bold</b>...</p>`,
+		},
+		{
+			opts:  ExcerptOptions{MaxWords: 6, Ellipsis: "..."},
+			out:   `<p>Hello <b>bold world</b> again</p><ul><li>one two...</li></ul>`,
+			cards: 1,
+		},
+		{
+			opts: ExcerptOptions{MaxWords: 7, SkipCards: true, Ellipsis: "..."},
+			out:  `<p>Hello <b>bold world</b> again</p><ul><li>one two</li><li>three...</li></ul>`,
+		},
+		{
+			opts: ExcerptOptions{MaxWords: 8, SkipCards: true, Ellipsis: "..."},
+			out:  `<p>Hello <b>bold world</b> again</p><ul><li>one two</li><li>three</li></ul><h2>Title</h2>`,
+		},
+	}
+
+	for _, item := range table {
+		res := Excerpt(doc, item.opts)
+		assert.Len(t, res.Cards, item.cards)
+
+		out, err := RenderHTML(res)
+		assert.NoError(t, err)
+		assert.Equal(t, template.HTML(item.out), out)
+	}
+}
+
+func TestExcerptSections(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("Hello world").
+		Card("card", Map{}).
+		List("ul", "one", "wonderful").
+		Build()
+	require.NoError(t, err)
+
+	res := Excerpt(doc, ExcerptOptions{MaxSections: 2, Ellipsis: "..."})
+	assert.Len(t, res.Sections, 2)
+	assert.Len(t, res.Cards, 1)
+
+	res = Excerpt(doc, ExcerptOptions{MaxSections: 2, SkipCards: true, Ellipsis: "..."})
+	assert.Empty(t, res.Cards)
+
+	out, err := RenderHTML(res)
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML(`<p>Hello world</p><ul><li>one</li><li>wonderful</li></ul>`), out)
+
+	res = Excerpt(doc, ExcerptOptions{MaxChars: 16, Ellipsis: "..."})
+	out, err = RenderHTML(res)
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML(`<p>Hello world</p><ul><li>one...</li></ul>`), out)
+
+	res = Excerpt(doc, ExcerptOptions{MaxChars: 12, Ellipsis: "..."})
+	assert.Len(t, res.Sections, 2)
+}
+
+func TestExcerptAtoms(t *testing.T) {
+	doc, err := NewBuilder().
+		Heading(2).Text("Ti").Atom("atom", "@a", Map{}).Text("tle", Italic).
+		Build()
+	require.NoError(t, err)
+
+	res := Excerpt(doc, ExcerptOptions{MaxChars: 3, Ellipsis: "..."})
+	out, err := RenderHTML(res)
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML(`<h2>Ti@a...</h2>`), out)
+
+	res = Excerpt(doc, ExcerptOptions{MaxChars: 6})
+	out, err = RenderHTML(res)
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML(`<h2>Ti@a<i>tle</i></h2>`), out)
+	assert.Len(t, res.Atoms, 1)
+	assert.Same(t, &res.Atoms[0], res.Sections[0].Markers[1].Atom)
+}
@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/stretchr/testify v1.7.0
 	go.mongodb.org/mongo-driver v1.9.1
+	golang.org/x/net v0.7.0
 )
 
 require (
@@ -1,7 +1,9 @@
 package mobiledoc
 
 import (
+	"net/url"
 	"reflect"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -17,6 +19,52 @@ func contains(list []string, str string) bool {
 	return false
 }
 
+var urlAttributes = []string{
+	"action", "background", "cite", "data", "formaction", "href", "icon",
+	"longdesc", "manifest", "poster", "src", "srcset", "usemap", "xlink:href",
+}
+
+func allowedAttribute(key, value string, schemes []string) bool {
+	// check key
+	key = strings.ToLower(key)
+	if !contains(urlAttributes, key) {
+		return true
+	}
+
+	// check candidates of source sets
+	if key == "srcset" {
+		for _, candidate := range strings.Split(value, ",") {
+			fields := strings.Fields(candidate)
+			if len(fields) > 0 && !allowedURL(fields[0], schemes) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return allowedURL(value, schemes)
+}
+
+func allowedURL(str string, schemes []string) bool {
+	// parse url
+	u, err := url.Parse(strings.TrimSpace(str))
+	if err != nil {
+		return false
+	}
+
+	// allow relative urls
+	if u.Scheme == "" {
+		return true
+	}
+
+	// ensure schemes
+	if schemes == nil {
+		schemes = DefaultSchemes
+	}
+
+	return contains(schemes, strings.ToLower(u.Scheme))
+}
+
 func toInt(v interface{}) (int, bool) {
 	// convert numbers
 	switch i := v.(type) {
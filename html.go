@@ -2,41 +2,109 @@ package mobiledoc
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"html"
 	"io"
-	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 )
 
+var htmlTagName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+var htmlAttributeName = regexp.MustCompile(`^[^\x00-\x20"'>/=\x7f]+$`)
+
+var htmlUnsafeTags = []string{
+	"applet", "base", "basefont", "embed", "form", "frame", "frameset", "iframe",
+	"input", "link", "math", "meta", "noembed", "noframes", "noscript", "object",
+	"param", "plaintext", "script", "style", "svg", "template", "textarea",
+	"title", "xmp",
+}
+
+var htmlUnsafeAttributes = []string{
+	"srcdoc", "style",
+}
+
+// DefaultSchemes defines the default URL schemes allowed for links and images.
+var DefaultSchemes = []string{"http", "https", "mailto"}
+
+// Element describes an HTML element by its tag and attributes. Elements with
+// an empty tag are omitted while their content is still rendered.
+type Element struct {
+	Tag        string
+	Attributes Map
+}
+
 // HTMLRenderer implements a basic HTML renderer.
 type HTMLRenderer struct {
 	Atoms map[string]func(*bufio.Writer, string, Map) error
 	Cards map[string]func(*bufio.Writer, Map) error
+
+	// ContextAtoms and ContextCards are context-aware atom and card renderers
+	// that also receive the location of the node. They take precedence over
+	// Atoms and Cards.
+	ContextAtoms map[string]func(context.Context, *bufio.Writer, Location, *Atom) error
+	ContextCards map[string]func(context.Context, *bufio.Writer, Location, *Card) error
+
+	// Fallback configures the handling of unknown atoms and cards. Placeholders
+	// are empty elements with a "data-atom" or "data-card" attribute.
+	Fallback
+
+	// Section may return the element used for markup and list sections.
+	// Defaults to the section tag and attributes.
+	Section func(section *Section) (Element, error)
+
+	// Item may return the element used for list items. Defaults to "li".
+	Item func(section *Section, index int) (Element, error)
+
+	// Markup may return the element used for markups. Defaults to the markup
+	// tag and attributes.
+	Markup func(markup *Markup) (Element, error)
+
+	// Image may render image sections. Defaults to an "img" element.
+	Image func(w *bufio.Writer, section *Section) error
+
+	// Text may render text markers. Defaults to the escaped text.
+	Text func(w *bufio.Writer, text string) error
+
+	// Schemes defines the allowed URL schemes for URL-valued attributes like
+	// "href" and "src" and image sources. Relative URLs are always allowed.
+	// Disallowed attributes are omitted and image sections with a disallowed
+	// source are skipped. If missing, DefaultSchemes is used.
+	Schemes []string
 }
 
 // NewHTMLRenderer creates a new HTMLRenderer.
 func NewHTMLRenderer() *HTMLRenderer {
 	return &HTMLRenderer{
-		Atoms: make(map[string]func(*bufio.Writer, string, Map) error),
-		Cards: make(map[string]func(*bufio.Writer, Map) error),
+		Atoms:        make(map[string]func(*bufio.Writer, string, Map) error),
+		Cards:        make(map[string]func(*bufio.Writer, Map) error),
+		ContextAtoms: make(map[string]func(context.Context, *bufio.Writer, Location, *Atom) error),
+		ContextCards: make(map[string]func(context.Context, *bufio.Writer, Location, *Card) error),
+		Schemes:      DefaultSchemes,
 	}
 }
 
 // Render will render the document to the provided writer.
 func (r *HTMLRenderer) Render(w io.Writer, doc Document) error {
+	return r.RenderContext(context.Background(), w, doc)
+}
+
+// RenderContext will render the document to the provided writer. The context
+// is passed to the context-aware atom and card renderers and rendering is
+// aborted if the context is cancelled.
+func (r *HTMLRenderer) RenderContext(ctx context.Context, w io.Writer, doc Document) error {
 	// wrap writer
 	bw := bufio.NewWriter(w)
 
-	// render sections
-	for _, section := range doc.Sections {
-		err := r.renderSection(bw, section)
-		if err != nil {
-			return err
-		}
+	// walk document
+	err := Walk(doc, contextVisitor(ctx, r.visitor(ctx, bw, doc)))
+	if err != nil {
+		return err
 	}
 
 	// flush buffer
-	err := bw.Flush()
+	err = bw.Flush()
 	if err != nil {
 		return err
 	}
@@ -44,53 +112,112 @@ func (r *HTMLRenderer) Render(w io.Writer, doc Document) error {
 	return nil
 }
 
-func (r *HTMLRenderer) renderSection(w *bufio.Writer, section Section) error {
-	// select sub renderer based on type
-	switch section.Type {
-	case MarkupSection:
-		return r.renderMarkupSection(w, section)
-	case ImageSection:
-		return r.renderImageSection(w, section)
-	case ListSection:
-		return r.renderListSection(w, section)
-	case CardSection:
-		return r.renderCardSection(w, section)
-	}
-
-	return nil
-}
+func (r *HTMLRenderer) visitor(ctx context.Context, w *bufio.Writer, doc Document) Visitor {
+	// prepare elements, markups are tracked using a stack
+	var section, item Element
+	var stack []Element
+
+	return Visitor{
+		EnterSection: func(_ Location, s *Section) error {
+			// select sub renderer based on type
+			switch s.Type {
+			case MarkupSection, ListSection:
+				var err error
+				section, err = r.sectionElement(s)
+				if err != nil {
+					return err
+				}
+				return r.renderOpenTag(w, section)
+			case ImageSection:
+				return r.renderImageSection(w, s)
+			}
+			return nil
+		},
+		LeaveSection: func(_ Location, s *Section) error {
+			// write close tag
+			if s.Type == MarkupSection || s.Type == ListSection {
+				return r.renderCloseTag(w, section)
+			}
+			return nil
+		},
+		EnterItem: func(loc Location, _ []Marker) error {
+			// get element
+			item = Element{Tag: "li"}
+			if r.Item != nil {
+				var err error
+				item, err = r.Item(&doc.Sections[loc.Section], loc.Item)
+				if err != nil {
+					return err
+				}
+			}
+			return r.renderOpenTag(w, item)
+		},
+		LeaveItem: func(Location, []Marker) error {
+			return r.renderCloseTag(w, item)
+		},
+		OpenMarkup: func(_ Location, markup *Markup) error {
+			// get element
+			element := Element{Tag: markup.Tag, Attributes: markup.Attributes}
+			if r.Markup != nil {
+				var err error
+				element, err = r.Markup(markup)
+				if err != nil {
+					return err
+				}
+			}
 
-func (r *HTMLRenderer) renderMarkupSection(w *bufio.Writer, section Section) error {
-	// write open tag
-	_, err := w.WriteString(fmt.Sprintf("<%s>", section.Tag))
-	if err != nil {
-		return err
-	}
+			// push element
+			stack = append(stack, element)
+
+			return r.renderOpenTag(w, element)
+		},
+		CloseMarkup: func(Location, *Markup) error {
+			// pop element
+			element := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			return r.renderCloseTag(w, element)
+		},
+		Text: func(_ Location, text string) error {
+			// call hook
+			if r.Text != nil {
+				return r.Text(w, text)
+			}
 
-	// render markers
-	err = r.renderMarkers(w, section.Markers)
-	if err != nil {
-		return err
+			_, err := w.WriteString(html.EscapeString(text))
+			return err
+		},
+		Atom: func(loc Location, atom *Atom) error {
+			return r.renderAtom(ctx, w, loc, atom)
+		},
+		Card: func(loc Location, card *Card) error {
+			return r.renderCard(ctx, w, loc, card)
+		},
 	}
+}
 
-	// write close tag
-	_, err = w.WriteString(fmt.Sprintf("</%s>", section.Tag))
-	if err != nil {
-		return err
+func (r *HTMLRenderer) sectionElement(section *Section) (Element, error) {
+	// call hook
+	if r.Section != nil {
+		return r.Section(section)
 	}
 
-	return nil
+	return Element{Tag: section.Tag, Attributes: section.Attributes}, nil
 }
 
-func (r *HTMLRenderer) renderImageSection(w *bufio.Writer, section Section) error {
-	// parse url
-	src, err := url.Parse(section.Source)
-	if err != nil {
-		return err
+func (r *HTMLRenderer) renderImageSection(w *bufio.Writer, section *Section) error {
+	// check source
+	if !r.allowedURL(section.Source) {
+		return nil
+	}
+
+	// call hook
+	if r.Image != nil {
+		return r.Image(w, section)
 	}
 
 	// write tag
-	_, err = w.WriteString(fmt.Sprintf("<img src=\"%s\">", src.String()))
+	_, err := w.WriteString(fmt.Sprintf("<img src=\"%s\">", html.EscapeString(section.Source)))
 	if err != nil {
 		return err
 	}
@@ -98,36 +225,20 @@ func (r *HTMLRenderer) renderImageSection(w *bufio.Writer, section Section) erro
 	return nil
 }
 
-func (r *HTMLRenderer) renderListSection(w *bufio.Writer, section Section) error {
-	// write open tag
-	_, err := w.WriteString(fmt.Sprintf("<%s>", section.Tag))
-	if err != nil {
-		return err
+func (r *HTMLRenderer) renderCard(ctx context.Context, w *bufio.Writer, loc Location, card *Card) error {
+	// get context card renderer
+	if renderer, ok := r.ContextCards[card.Name]; ok {
+		return renderer(ctx, w, loc, card)
 	}
 
-	// write all items
-	for _, item := range section.Items {
-		// write open tag
-		_, err := w.WriteString("<li>")
-		if err != nil {
-			return err
-		}
-
-		// render markers
-		err = r.renderMarkers(w, item)
-		if err != nil {
-			return err
-		}
-
-		// write close tag
-		_, err = w.WriteString("</li>")
-		if err != nil {
-			return err
-		}
+	// get card renderer
+	renderer, ok := r.Cards[card.Name]
+	if !ok {
+		return r.Fallback.renderCard(ctx, w, loc, card, fmt.Sprintf("<div data-card=\"%s\"></div>", html.EscapeString(card.Name)))
 	}
 
-	// write close tag
-	_, err = w.WriteString(fmt.Sprintf("</%s>", section.Tag))
+	// call renderer
+	err := renderer(w, card.Payload)
 	if err != nil {
 		return err
 	}
@@ -135,15 +246,20 @@ func (r *HTMLRenderer) renderListSection(w *bufio.Writer, section Section) error
 	return nil
 }
 
-func (r *HTMLRenderer) renderCardSection(w *bufio.Writer, section Section) error {
-	// get card renderer
-	renderer, ok := r.Cards[section.Card.Name]
+func (r *HTMLRenderer) renderAtom(ctx context.Context, w *bufio.Writer, loc Location, atom *Atom) error {
+	// get context atom renderer
+	if renderer, ok := r.ContextAtoms[atom.Name]; ok {
+		return renderer(ctx, w, loc, atom)
+	}
+
+	// get renderer
+	renderer, ok := r.Atoms[atom.Name]
 	if !ok {
-		return fmt.Errorf("missing card renderer")
+		return r.Fallback.renderAtom(ctx, w, loc, atom, html.EscapeString(atom.Text), fmt.Sprintf("<span data-atom=\"%s\"></span>", html.EscapeString(atom.Name)))
 	}
 
 	// call renderer
-	err := renderer(w, section.Card.Payload)
+	err := renderer(w, atom.Text, atom.Payload)
 	if err != nil {
 		return err
 	}
@@ -151,86 +267,85 @@ func (r *HTMLRenderer) renderCardSection(w *bufio.Writer, section Section) error
 	return nil
 }
 
-func (r *HTMLRenderer) renderMarkers(w *bufio.Writer, markers []Marker) error {
-	// prepare stack
-	stack := markupStack{}
-
-	// write all markers
-	for _, marker := range markers {
-		// write opening markups
-		for _, markup := range marker.OpenMarkups {
-			// begin tag
-			_, err := w.WriteString(fmt.Sprintf("<%s", markup.Tag))
-			if err != nil {
-				return err
-			}
+func (r *HTMLRenderer) renderOpenTag(w *bufio.Writer, element Element) error {
+	// get tag and attributes
+	tag := element.Tag
+	attributes := element.Attributes
 
-			// write attributes
-			for key, value := range markup.Attributes {
-				_, err = w.WriteString(fmt.Sprintf(" %s=\"%s\"", key, value))
-				if err != nil {
-					return err
-				}
-			}
+	// skip omitted elements
+	if tag == "" {
+		return nil
+	}
 
-			// close tag
-			_, err = w.WriteString(">")
-			if err != nil {
-				return err
-			}
+	// check tag
+	if !htmlTagName.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q", tag)
+	} else if contains(htmlUnsafeTags, strings.ToLower(tag)) {
+		return fmt.Errorf("unsafe tag %q", tag)
+	}
 
-			// push markup
-			stack.push(markup)
-		}
+	// begin tag
+	_, err := w.WriteString(fmt.Sprintf("<%s", tag))
+	if err != nil {
+		return err
+	}
 
-		// write marker
-		switch marker.Type {
-		case TextMarker:
-			// write text
-			_, err := w.WriteString(html.EscapeString(marker.Text))
-			if err != nil {
-				return err
-			}
-		case AtomMarker:
-			// get renderer
-			renderer, ok := r.Atoms[marker.Atom.Name]
-			if !ok {
-				return fmt.Errorf("missing atom renderer")
-			}
+	// sort keys
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-			// call renderer
-			err := renderer(w, marker.Atom.Text, marker.Atom.Payload)
-			if err != nil {
-				return err
-			}
+	// write attributes
+	for _, key := range keys {
+		// check name
+		if !htmlAttributeName.MatchString(key) {
+			return fmt.Errorf("invalid attribute %q", key)
 		}
 
-		// close markups
-		for i := 0; i < marker.ClosedMarkups; i++ {
-			// get markup
-			markup := stack.pop()
+		// get value
+		value := fmt.Sprint(attributes[key])
 
-			// write closing tag
-			_, err := w.WriteString(fmt.Sprintf("</%s>", markup.Tag))
-			if err != nil {
-				return err
-			}
+		// skip event handlers, styles and disallowed urls
+		name := strings.ToLower(key)
+		if strings.HasPrefix(name, "on") || contains(htmlUnsafeAttributes, name) {
+			continue
+		} else if !allowedAttribute(name, value, r.Schemes) {
+			continue
+		}
+
+		// write attribute
+		_, err = w.WriteString(fmt.Sprintf(" %s=\"%s\"", key, html.EscapeString(value)))
+		if err != nil {
+			return err
 		}
 	}
 
+	// close tag
+	_, err = w.WriteString(">")
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-type markupStack struct {
-	list []*Markup
-}
+func (r *HTMLRenderer) renderCloseTag(w *bufio.Writer, element Element) error {
+	// skip omitted elements
+	if element.Tag == "" {
+		return nil
+	}
 
-func (s *markupStack) push(m *Markup) {
-	s.list = append(s.list, m)
+	// write tag
+	_, err := w.WriteString(fmt.Sprintf("</%s>", element.Tag))
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
-func (s *markupStack) pop() *Markup {
-	item := s.list[len(s.list)-1]
-	s.list = s.list[0 : len(s.list)-1]
-	return item
+func (r *HTMLRenderer) allowedURL(str string) bool {
+	return allowedURL(str, r.Schemes)
 }
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,3 +36,203 @@ func TestHTMLRenderer(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, out, buf.String())
 }
+
+func TestHTMLRendererSectionAttributes(t *testing.T) {
+	doc := Document{
+		Version: Version032,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Attributes: Map{"data-md-text-align": "center"}, Markers: []Marker{
+				{Type: TextMarker, Text: "foo"},
+			}},
+			{Type: ListSection, Tag: "ul", Attributes: Map{"data-md-text-align": "right"}, Items: [][]Marker{
+				{{Type: TextMarker, Text: "bar"}},
+			}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := NewHTMLRenderer().Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p data-md-text-align="center">foo</p><ul data-md-text-align="right"><li>bar</li></ul>`, buf.String())
+}
+
+func TestHTMLRendererEscaping(t *testing.T) {
+	doc := Document{
+		Version: Version031,
+		Markups: []Markup{
+			{Tag: "a", Attributes: Map{"href": `https://example.com/?a="b"&c`, "title": "<x>", "data-id": 1}},
+			{Tag: "a", Attributes: Map{"href": "javascript:alert(1)"}},
+			{Tag: "a", Attributes: Map{"href": "JavaScript:alert(1)"}},
+			{Tag: "a", Attributes: Map{"href": "/relative"}},
+		},
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{
+				{Type: TextMarker, Text: "a"},
+				{Type: TextMarker, Text: "b"},
+				{Type: TextMarker, Text: "c"},
+				{Type: TextMarker, Text: "d"},
+			}},
+			{Type: ImageSection, Source: `https://example.com/"foo".png`},
+			{Type: ImageSection, Source: "javascript:alert(1)"},
+		},
+	}
+	for i := range doc.Markups {
+		doc.Sections[0].Markers[i].OpenMarkups = []*Markup{&doc.Markups[i]}
+		doc.Sections[0].Markers[i].ClosedMarkups = 1
+	}
+
+	buf := &bytes.Buffer{}
+	err := NewHTMLRenderer().Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p><a data-id="1" href="https://example.com/?a=&#34;b&#34;&amp;c" title="&lt;x&gt;">a</a><a>b</a><a>c</a><a href="/relative">d</a></p><img src="https://example.com/&#34;foo&#34;.png">`, buf.String())
+
+	r := NewHTMLRenderer()
+	r.Schemes = []string{"javascript"}
+	buf.Reset()
+	err = r.Render(buf, Document{Sections: []Section{{Type: ImageSection, Source: "javascript:alert(1)"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<img src="javascript:alert(1)">`, buf.String())
+
+	err = NewHTMLRenderer().Render(buf, Document{Sections: []Section{
+		{Type: MarkupSection, Tag: "p onclick=x"},
+	}})
+	assert.Error(t, err)
+
+	err = NewHTMLRenderer().Render(buf, Document{Sections: []Section{
+		{Type: MarkupSection, Tag: "p", Attributes: Map{"a=b": "c"}},
+	}})
+	assert.Error(t, err)
+}
+
+func TestHTMLRendererNilSchemes(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("x", Link("https://example.com")).Text("y", Link("javascript:alert(1)")).
+		Image("https://example.com/foo.png").
+		Build()
+	assert.NoError(t, err)
+
+	r := &HTMLRenderer{
+		Atoms: map[string]func(*bufio.Writer, string, Map) error{},
+		Cards: map[string]func(*bufio.Writer, Map) error{},
+	}
+
+	buf := &bytes.Buffer{}
+	err = r.Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p><a href="https://example.com">x</a><a>y</a></p><img src="https://example.com/foo.png">`, buf.String())
+}
+
+func TestHTMLRendererUnsafeTags(t *testing.T) {
+	for _, tag := range []string{"script", "SCRIPT", "style", "iframe", "object", "embed", "svg", "base", "meta"} {
+		buf := &bytes.Buffer{}
+		err := NewHTMLRenderer().Render(buf, Document{Sections: []Section{
+			{Type: MarkupSection, Tag: tag, Markers: []Marker{{Type: TextMarker, Text: "alert(1)"}}},
+		}})
+		assert.Error(t, err, tag)
+		assert.Empty(t, buf.String(), tag)
+	}
+
+	doc := Document{
+		Markups: []Markup{{Tag: "script"}},
+		Sections: []Section{{Type: MarkupSection, Tag: "p", Markers: []Marker{
+			{Type: TextMarker, Text: "alert(1)"},
+		}}},
+	}
+	doc.Sections[0].Markers[0].OpenMarkups = []*Markup{&doc.Markups[0]}
+	doc.Sections[0].Markers[0].ClosedMarkups = 1
+
+	err := NewHTMLRenderer().Render(&bytes.Buffer{}, doc)
+	assert.Error(t, err)
+}
+
+func TestHTMLRendererUnsafeAttributes(t *testing.T) {
+	doc := Document{
+		Markups: []Markup{
+			{Tag: "a", Attributes: Map{"href": "https://x", "onmouseover": "alert(1)", "OnClick": "alert(1)"}},
+			{Tag: "span", Attributes: Map{"style": "background:url(javascript:alert(1))", "title": "x"}},
+			{Tag: "img", Attributes: Map{"src": "javascript:alert(1)", "srcset": "/a.png 1x, javascript:alert(1) 2x"}},
+			{Tag: "img", Attributes: Map{"src": "/a.png", "srcset": "/a.png 1x, https://x/b.png 2x"}},
+			{Tag: "button", Attributes: Map{"formaction": "javascript:alert(1)", "srcdoc": "<script></script>"}},
+			{Tag: "q", Attributes: Map{"cite": "vbscript:x"}},
+		},
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Attributes: Map{"onload": "alert(1)", "style": "color:red", "id": "p"}, Markers: []Marker{
+				{Type: TextMarker, Text: "a"},
+				{Type: TextMarker, Text: "b"},
+				{Type: TextMarker, Text: "c"},
+				{Type: TextMarker, Text: "d"},
+				{Type: TextMarker, Text: "e"},
+				{Type: TextMarker, Text: "f"},
+			}},
+		},
+	}
+	for i := range doc.Markups {
+		doc.Sections[0].Markers[i].OpenMarkups = []*Markup{&doc.Markups[i]}
+		doc.Sections[0].Markers[i].ClosedMarkups = 1
+	}
+
+	buf := &bytes.Buffer{}
+	err := NewHTMLRenderer().Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p id="p">`+
+		`<a href="https://x">a</a>`+
+		`<span title="x">b</span>`+
+		`<img>c</img>`+
+		`<img src="/a.png" srcset="/a.png 1x, https://x/b.png 2x">d</img>`+
+		`<button>e</button>`+
+		`<q>f</q>`+
+		`</p>`, buf.String())
+}
+
+func TestHTMLRendererHooks(t *testing.T) {
+	doc, err := NewBuilder().
+		Section("aside").Text("foo ").Text("bar", Link("https://example.com")).Text(" baz", Bold).
+		List("ul", "one").
+		Image("/foo.png").
+		Build()
+	assert.NoError(t, err)
+
+	r := NewHTMLRenderer()
+	r.Section = func(section *Section) (Element, error) {
+		if section.Tag == "aside" {
+			return Element{Tag: "div", Attributes: Map{"class": "aside"}}, nil
+		}
+		return Element{Tag: section.Tag, Attributes: section.Attributes}, nil
+	}
+	r.Item = func(section *Section, index int) (Element, error) {
+		return Element{Tag: "li", Attributes: Map{"data-index": index}}, nil
+	}
+	r.Markup = func(markup *Markup) (Element, error) {
+		switch markup.Tag {
+		case "a":
+			return Element{Tag: "a", Attributes: Map{
+				"href":   markup.Attributes["href"],
+				"target": "_blank",
+				"rel":    "noopener",
+			}}, nil
+		case "b":
+			return Element{}, nil
+		}
+		return Element{Tag: markup.Tag, Attributes: markup.Attributes}, nil
+	}
+	r.Image = func(w *bufio.Writer, section *Section) error {
+		_, err := w.WriteString(fmt.Sprintf(`<figure><img src="%s" srcset="%s 2x"></figure>`, section.Source, section.Source))
+		return err
+	}
+	r.Text = func(w *bufio.Writer, text string) error {
+		_, err := w.WriteString(strings.ToUpper(text))
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	err = r.Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, `<div class="aside">FOO <a href="https://example.com" rel="noopener" target="_blank">BAR</a> BAZ</div><ul><li data-index="0">ONE</li></ul><figure><img src="/foo.png" srcset="/foo.png 2x"></figure>`, buf.String())
+
+	r.Markup = func(markup *Markup) (Element, error) {
+		return Element{Tag: "<x>"}, nil
+	}
+
+	err = r.Render(&bytes.Buffer{}, doc)
+	assert.Error(t, err)
+}
@@ -0,0 +1,306 @@
+package mobiledoc
+
+import "strconv"
+
+// ParseAny will parse the specified raw structure of any supported version
+// into a document. The version of the document is retained and legacy
+// documents must be upgraded using Upgrade before they can be compiled.
+// Problems are returned as a *ParseError.
+func ParseAny(doc Map) (Document, error) {
+	// get version
+	version, ok := doc["version"].(string)
+	if !ok {
+		return Parse(doc)
+	}
+
+	// parse document
+	switch version {
+	case Version020:
+		return parseLegacy(doc)
+	case Version030, Version031, Version032:
+		return Parse(doc)
+	default:
+		return Document{}, parseError(ErrInvalidVersion, "supported version", strconv.Quote(version), "version")
+	}
+}
+
+// Upgrade will upgrade the specified legacy document to the current version.
+// Documents of a current version are returned unchanged.
+func Upgrade(doc Document) Document {
+	// set version
+	if doc.Version == Version020 || doc.Version == Version030 {
+		doc.Version = Version
+	}
+
+	return doc
+}
+
+func parseLegacy(doc Map) (Document, error) {
+	// prepare document
+	d := Document{Version: Version020}
+
+	// check sections
+	value, ok := doc["sections"]
+	if !ok || value == nil {
+		return d, nil
+	}
+
+	// coerce value
+	pair, ok := toList(value)
+	if !ok {
+		return d, parseError(ErrInvalidValue, "list", describe(value), "sections")
+	} else if len(pair) != 2 {
+		return d, parseError(ErrInvalidLength, "2 elements", describeLength(pair), "sections")
+	}
+
+	// coerce markups
+	markups, ok := toList(pair[0])
+	if !ok {
+		return d, parseError(ErrInvalidValue, "list", describe(pair[0]), "sections", 0)
+	}
+
+	// allocate markups
+	d.Markups = make([]Markup, 0, len(markups))
+
+	// parse markups
+	for i, value := range markups {
+		// coerce value
+		markup, ok := toList(value)
+		if !ok {
+			return d, parseError(ErrInvalidValue, "list", describe(value), "sections", 0, i)
+		}
+
+		// parse markup
+		m, err := parseMarkup(markup)
+		if err != nil {
+			return d, prefixError(err, "sections", 0, i)
+		}
+
+		// add markup
+		d.Markups = append(d.Markups, m)
+	}
+
+	// coerce sections
+	sections, ok := toList(pair[1])
+	if !ok {
+		return d, parseError(ErrInvalidValue, "list", describe(pair[1]), "sections", 1)
+	}
+
+	// count cards
+	cards := 0
+	for _, value := range sections {
+		section, ok := toList(value)
+		if ok && len(section) > 0 {
+			typ, ok := toInt(section[0])
+			if ok && SectionType(typ) == CardSection {
+				cards++
+			}
+		}
+	}
+
+	// allocate cards and sections, the cards are allocated upfront to keep
+	// the section pointers stable
+	d.Cards = make([]Card, 0, cards)
+	d.Sections = make([]Section, 0, len(sections))
+
+	// parse sections
+	for i, value := range sections {
+		// coerce value
+		section, ok := toList(value)
+		if !ok {
+			return d, parseError(ErrInvalidValue, "list", describe(value), "sections", 1, i)
+		}
+
+		// parse section
+		s, err := parseLegacySection(section, &d)
+		if err != nil {
+			return d, prefixError(err, "sections", 1, i)
+		}
+
+		// add section
+		d.Sections = append(d.Sections, s)
+	}
+
+	return d, nil
+}
+
+func parseLegacySection(section List, doc *Document) (Section, error) {
+	// prepare section
+	s := Section{}
+
+	// check length
+	if len(section) == 0 {
+		return s, parseError(ErrInvalidLength, "at least 1 element", describeLength(section))
+	}
+
+	// get section type
+	typ, ok := toInt(section[0])
+	if !ok {
+		return s, parseError(ErrInvalidValue, "number", describe(section[0]), 0)
+	}
+
+	// parse section
+	switch SectionType(typ) {
+	case MarkupSection:
+		return parseLegacyMarkupSection(section, doc.Markups)
+	case ImageSection:
+		return parseImageSection(section)
+	case ListSection:
+		return parseLegacyListSection(section, doc.Markups)
+	case CardSection:
+		return parseLegacyCardSection(section, doc)
+	default:
+		return s, parseError(ErrInvalidSectionType, "section type", strconv.Itoa(typ), 0)
+	}
+}
+
+func parseLegacyMarkupSection(section List, markups []Markup) (Section, error) {
+	// prepare section
+	s := Section{Type: MarkupSection}
+
+	// check length
+	if len(section) != 3 {
+		return s, parseError(ErrInvalidLength, "3 elements", describeLength(section))
+	}
+
+	// get tag
+	tag, ok := section[1].(string)
+	if !ok {
+		return s, parseError(ErrInvalidValue, "string", describe(section[1]), 1)
+	}
+
+	// set tag
+	s.Tag = tag
+
+	// get items
+	items, ok := toList(section[2])
+	if !ok {
+		return s, parseError(ErrInvalidValue, "list", describe(section[2]), 2)
+	}
+
+	// parse markers
+	markers, err := parseLegacyMarkers(items, markups)
+	if err != nil {
+		return s, prefixError(err, 2)
+	}
+
+	// set markers
+	s.Markers = markers
+
+	return s, nil
+}
+
+func parseLegacyListSection(list List, markups []Markup) (Section, error) {
+	// prepare section
+	s := Section{Type: ListSection}
+
+	// check length
+	if len(list) != 3 {
+		return s, parseError(ErrInvalidLength, "3 elements", describeLength(list))
+	}
+
+	// get tag
+	tag, ok := list[1].(string)
+	if !ok {
+		return s, parseError(ErrInvalidValue, "string", describe(list[1]), 1)
+	}
+
+	// set tag
+	s.Tag = tag
+
+	// get items
+	items, ok := toList(list[2])
+	if !ok {
+		return s, parseError(ErrInvalidValue, "list", describe(list[2]), 2)
+	}
+
+	// allocate items
+	s.Items = make([][]Marker, 0, len(items))
+
+	// parse items
+	for i, value := range items {
+		// coerce value
+		item, ok := toList(value)
+		if !ok {
+			return s, parseError(ErrInvalidValue, "list", describe(value), 2, i)
+		}
+
+		// parse markers
+		markers, err := parseLegacyMarkers(item, markups)
+		if err != nil {
+			return s, prefixError(err, 2, i)
+		}
+
+		// add item
+		s.Items = append(s.Items, markers)
+	}
+
+	return s, nil
+}
+
+func parseLegacyCardSection(card List, doc *Document) (Section, error) {
+	// prepare section
+	s := Section{Type: CardSection}
+
+	// check length
+	if len(card) != 3 {
+		return s, parseError(ErrInvalidLength, "3 elements", describeLength(card))
+	}
+
+	// get name
+	name, ok := card[1].(string)
+	if !ok {
+		return s, parseError(ErrInvalidValue, "string", describe(card[1]), 1)
+	}
+
+	// get payload
+	payload, ok := toMap(card[2])
+	if !ok {
+		return s, parseError(ErrInvalidValue, "map", describe(card[2]), 2)
+	}
+
+	// add card
+	doc.Cards = append(doc.Cards, Card{
+		Name:    name,
+		Payload: payload,
+	})
+
+	// set card
+	s.Card = &doc.Cards[len(doc.Cards)-1]
+
+	return s, nil
+}
+
+func parseLegacyMarkers(items List, markups []Markup) ([]Marker, error) {
+	// prepare open markup counter
+	openMarkups := 0
+
+	// prepare vars
+	var err error
+	var m Marker
+
+	// allocate markers
+	markers := make([]Marker, 0, len(items))
+
+	// parse markers
+	for i, value := range items {
+		// coerce value
+		marker, ok := toList(value)
+		if !ok {
+			return nil, parseError(ErrInvalidValue, "list", describe(value), i)
+		} else if len(marker) != 3 {
+			return nil, parseError(ErrInvalidLength, "3 elements", describeLength(marker), i)
+		}
+
+		// parse marker as a text marker
+		m, openMarkups, err = parseMarkerValues(TextMarker, marker, 0, markups, nil, openMarkups)
+		if err != nil {
+			return nil, prefixError(err, i)
+		}
+
+		// add marker
+		markers = append(markers, m)
+	}
+
+	return markers, nil
+}
@@ -0,0 +1,161 @@
+package mobiledoc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const legacyJSON = `{
+	"version":"0.2.0",
+	"sections":[
+		[
+			["b"],
+			["a",["href","https://example.com"]]
+		],
+		[
+			[1,"h1",[
+				[[],0,"Title"]
+			]],
+			[1,"p",[
+				[[],0,"foo "],
+				[[0,1],2,"bar"]
+			]],
+			[2,"https://example.com/foo.png"],
+			[3,"ul",[
+				[[[],0,"foo"]],
+				[[[0],1,"bar"]]
+			]],
+			[10,"card1",{"foo":42}]
+		]
+	]
+}`
+
+func TestParseAnyLegacy(t *testing.T) {
+	var in Map
+	err := json.Unmarshal([]byte(legacyJSON), &in)
+	assert.NoError(t, err)
+
+	doc, err := ParseAny(in)
+	assert.NoError(t, err)
+
+	expected := Document{
+		Version: Version020,
+		Markups: []Markup{
+			{Tag: "b"},
+			{Tag: "a", Attributes: Map{"href": "https://example.com"}},
+		},
+		Cards: []Card{
+			{Name: "card1", Payload: Map{"foo": 42.0}},
+		},
+	}
+	expected.Sections = []Section{
+		{Type: MarkupSection, Tag: "h1", Markers: []Marker{
+			{Type: TextMarker, Text: "Title"},
+		}},
+		{Type: MarkupSection, Tag: "p", Markers: []Marker{
+			{Type: TextMarker, Text: "foo "},
+			{Type: TextMarker, OpenMarkups: []*Markup{&expected.Markups[0], &expected.Markups[1]}, ClosedMarkups: 2, Text: "bar"},
+		}},
+		{Type: ImageSection, Source: "https://example.com/foo.png"},
+		{Type: ListSection, Tag: "ul", Items: [][]Marker{
+			{{Type: TextMarker, Text: "foo"}},
+			{{Type: TextMarker, OpenMarkups: []*Markup{&expected.Markups[0]}, ClosedMarkups: 1, Text: "bar"}},
+		}},
+		{Type: CardSection, Card: &expected.Cards[0]},
+	}
+	assert.Equal(t, expected, doc)
+
+	_, err = Compile(doc)
+	assert.Error(t, err)
+
+	m, err := Compile(Upgrade(doc))
+	assert.NoError(t, err)
+	assert.Equal(t, Map{
+		"version": Version,
+		"markups": List{
+			List{"b"},
+			List{"a", List{"href", "https://example.com"}},
+		},
+		"atoms": List{},
+		"cards": List{
+			List{"card1", Map{"foo": 42.0}},
+		},
+		"sections": List{
+			List{MarkupSection, "h1", List{
+				List{TextMarker, List{}, 0, "Title"},
+			}},
+			List{MarkupSection, "p", List{
+				List{TextMarker, List{}, 0, "foo "},
+				List{TextMarker, List{0, 1}, 2, "bar"},
+			}},
+			List{ImageSection, "https://example.com/foo.png"},
+			List{ListSection, "ul", List{
+				List{List{TextMarker, List{}, 0, "foo"}},
+				List{List{TextMarker, List{0}, 1, "bar"}},
+			}},
+			List{CardSection, 0},
+		},
+	}, m)
+}
+
+func TestParseAny(t *testing.T) {
+	in := sampleMap()
+	in["version"] = Version030
+
+	doc, err := ParseAny(in)
+	assert.NoError(t, err)
+	assert.Equal(t, Version030, doc.Version)
+
+	doc = Upgrade(doc)
+	assert.Equal(t, Version, doc.Version)
+	assert.Equal(t, sampleDoc(), doc)
+
+	doc, err = ParseAny(sampleMap())
+	assert.NoError(t, err)
+	assert.Equal(t, sampleDoc(), doc)
+	assert.Equal(t, sampleDoc(), Upgrade(doc))
+
+	doc, err = ParseAny(Map{"version": Version020})
+	assert.NoError(t, err)
+	assert.Equal(t, Document{Version: Version020}, doc)
+}
+
+func TestParseAnyInvalid(t *testing.T) {
+	_, err := ParseAny(Map{})
+	assert.Error(t, err)
+
+	_, err = ParseAny(Map{"version": "0.1.0"})
+	assert.Error(t, err)
+
+	_, err = ParseAny(Map{
+		"version":  Version020,
+		"sections": List{List{}},
+	})
+	assert.Error(t, err)
+
+	_, err = ParseAny(Map{
+		"version":  Version020,
+		"sections": List{List{1}, List{}},
+	})
+	assert.Error(t, err)
+
+	_, err = ParseAny(Map{
+		"version":  Version020,
+		"sections": List{List{}, List{List{CardSection, "foo", 1}}},
+	})
+	assert.Error(t, err)
+
+	_, err = ParseAny(Map{
+		"version":  Version020,
+		"sections": List{List{}, List{List{MarkupSection, "p", List{List{List{0}, 0, "foo"}}}}},
+	})
+	assert.Error(t, err)
+
+	_, err = ParseAny(Map{
+		"version":  Version020,
+		"sections": List{List{}, List{List{ListSection, "ul", List{List{List{List{}, 0}}}}}},
+	})
+	assert.Error(t, err)
+}
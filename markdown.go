@@ -0,0 +1,463 @@
+package mobiledoc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+	`&`, `\&`,
+	`~`, `\~`,
+	"\n", "\\\n",
+)
+
+var markdownURLEscaper = strings.NewReplacer(
+	` `, `%20`,
+	`(`, `%28`,
+	`)`, `%29`,
+	`<`, `%3C`,
+	`>`, `%3E`,
+)
+
+var markdownLineStart = regexp.MustCompile(`(?m)^( {0,3})([#+=-]|[0-9]+[.)])`)
+
+var markdownIndent = regexp.MustCompile(`(?m)^(?: {4}| {0,3}\t)`)
+
+var markdownBackticks = regexp.MustCompile("`+")
+
+// MarkdownRenderer implements a basic CommonMark renderer. Strikethrough is
+// rendered using the common "~~" extension and markups without a Markdown
+// equivalent are rendered as inline HTML. Emphasis that cannot be expressed
+// using valid delimiter runs is rendered as inline HTML as well.
+type MarkdownRenderer struct {
+	Atoms map[string]func(*bufio.Writer, string, Map) error
+	Cards map[string]func(*bufio.Writer, Map) error
+
+	// ContextAtoms and ContextCards are context-aware atom and card renderers
+	// that also receive the location of the node. They take precedence over
+	// Atoms and Cards.
+	ContextAtoms map[string]func(context.Context, *bufio.Writer, Location, *Atom) error
+	ContextCards map[string]func(context.Context, *bufio.Writer, Location, *Card) error
+
+	// Fallback configures the handling of unknown atoms and cards. Placeholders
+	// are the escaped atom or card name in brackets.
+	Fallback
+
+	// Image may render image sections. Defaults to an inline image.
+	Image func(w *bufio.Writer, section *Section) error
+
+	// Text may render text markers. Defaults to the escaped text. Text within
+	// code spans is always written verbatim.
+	Text func(w *bufio.Writer, text string) error
+}
+
+// NewMarkdownRenderer creates a new MarkdownRenderer.
+func NewMarkdownRenderer() *MarkdownRenderer {
+	return &MarkdownRenderer{
+		Atoms:        make(map[string]func(*bufio.Writer, string, Map) error),
+		Cards:        make(map[string]func(*bufio.Writer, Map) error),
+		ContextAtoms: make(map[string]func(context.Context, *bufio.Writer, Location, *Atom) error),
+		ContextCards: make(map[string]func(context.Context, *bufio.Writer, Location, *Card) error),
+	}
+}
+
+// Render will render the document to the provided writer.
+func (r *MarkdownRenderer) Render(w io.Writer, doc Document) error {
+	return r.RenderContext(context.Background(), w, doc)
+}
+
+// RenderContext will render the document to the provided writer. The context
+// is passed to the context-aware atom and card renderers and rendering is
+// aborted if the context is cancelled.
+func (r *MarkdownRenderer) RenderContext(ctx context.Context, w io.Writer, doc Document) error {
+	// wrap writer
+	bw := bufio.NewWriter(w)
+
+	// walk document
+	err := Walk(doc, contextVisitor(ctx, r.visitor(ctx, bw, doc)))
+	if err != nil {
+		return err
+	}
+
+	// flush buffer
+	err = bw.Flush()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type markdownFrame struct {
+	markup *Markup
+	buffer strings.Builder
+}
+
+func (r *MarkdownRenderer) visitor(ctx context.Context, w *bufio.Writer, doc Document) Visitor {
+	// prepare stack, the first frame collects the content of a block
+	var stack []*markdownFrame
+
+	// prepare code counter
+	code := 0
+
+	// prepare heading flag
+	heading := false
+
+	// write blank line between sections that produced output
+	written := false
+	separate := func() error {
+		if written {
+			_, err := w.WriteString("\n\n")
+			if err != nil {
+				return err
+			}
+		}
+		written = true
+		return nil
+	}
+
+	return Visitor{
+		EnterSection: func(_ Location, section *Section) error {
+			// write blank line, cards are separated when rendered
+			if section.Type != CardSection {
+				err := separate()
+				if err != nil {
+					return err
+				}
+			}
+
+			// reset stack
+			stack = []*markdownFrame{{}}
+			code = 0
+
+			// check heading
+			heading = section.Type == MarkupSection && isHeading(section.Tag)
+
+			// write image
+			if section.Type == ImageSection {
+				if r.Image != nil {
+					return r.Image(w, section)
+				}
+				_, err := w.WriteString(fmt.Sprintf("![](%s)", markdownURLEscaper.Replace(section.Source)))
+				return err
+			}
+
+			return nil
+		},
+		LeaveSection: func(_ Location, section *Section) error {
+			// check type
+			if section.Type != MarkupSection {
+				return nil
+			}
+
+			// get content
+			content := r.content(stack)
+
+			// prefix content, headings must not span multiple lines
+			switch section.Tag {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				content = strings.Repeat("#", int(section.Tag[1]-'0')) + " " + strings.ReplaceAll(content, "\n", " ")
+			case "blockquote", "aside":
+				content = "> " + strings.ReplaceAll(content, "\n", "\n> ")
+			}
+
+			// write content
+			_, err := w.WriteString(content)
+
+			return err
+		},
+		EnterItem: func(loc Location, _ []Marker) error {
+			// write newline
+			if loc.Item > 0 {
+				_, err := w.WriteString("\n")
+				if err != nil {
+					return err
+				}
+			}
+
+			// reset stack
+			stack = []*markdownFrame{{}}
+			code = 0
+
+			return nil
+		},
+		LeaveItem: func(loc Location, _ []Marker) error {
+			// get prefix
+			prefix := "- "
+			if doc.Sections[loc.Section].Tag == "ol" {
+				prefix = fmt.Sprintf("%d. ", loc.Item+1)
+			}
+
+			// indent continuation lines
+			content := strings.ReplaceAll(r.content(stack), "\n", "\n"+strings.Repeat(" ", len(prefix)))
+
+			// write item
+			_, err := w.WriteString(prefix + content)
+
+			return err
+		},
+		OpenMarkup: func(_ Location, markup *Markup) error {
+			// push frame
+			stack = append(stack, &markdownFrame{markup: markup})
+			if markup.Tag == "code" {
+				code++
+			}
+
+			return nil
+		},
+		CloseMarkup: func(Location, *Markup) error {
+			// pop frame
+			stack, code = r.popFrame(stack, code)
+
+			return nil
+		},
+		Text: func(_ Location, text string) error {
+			// replace newlines in headings
+			if heading {
+				text = strings.ReplaceAll(text, "\n", " ")
+			}
+
+			// write text, code spans are written verbatim
+			buffer := &stack[len(stack)-1].buffer
+			if code > 0 {
+				buffer.WriteString(text)
+			} else if r.Text != nil {
+				bw := bufio.NewWriter(buffer)
+				err := r.Text(bw, text)
+				if err != nil {
+					return err
+				}
+				return bw.Flush()
+			} else {
+				buffer.WriteString(markdownEscaper.Replace(text))
+			}
+
+			return nil
+		},
+		Atom: func(loc Location, atom *Atom) error {
+			// render atom
+			bw := bufio.NewWriter(&stack[len(stack)-1].buffer)
+			err := r.renderAtom(ctx, bw, loc, atom)
+			if err != nil {
+				return err
+			}
+
+			return bw.Flush()
+		},
+		Card: func(loc Location, card *Card) error {
+			// render card
+			out, err := renderBuffered(func(bw *bufio.Writer) error {
+				return r.renderCard(ctx, bw, loc, card)
+			})
+			if err != nil || out == "" {
+				return err
+			}
+
+			// write blank line and card
+			err = separate()
+			if err != nil {
+				return err
+			}
+			_, err = w.WriteString(out)
+			return err
+		},
+	}
+}
+
+func (r *MarkdownRenderer) renderCard(ctx context.Context, w *bufio.Writer, loc Location, card *Card) error {
+	// get context card renderer
+	if renderer, ok := r.ContextCards[card.Name]; ok {
+		return renderer(ctx, w, loc, card)
+	}
+
+	// get card renderer
+	renderer, ok := r.Cards[card.Name]
+	if !ok {
+		return r.Fallback.renderCard(ctx, w, loc, card, markdownEscaper.Replace(fmt.Sprintf("[%s]", card.Name)))
+	}
+
+	// call renderer
+	err := renderer(w, card.Payload)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *MarkdownRenderer) renderAtom(ctx context.Context, w *bufio.Writer, loc Location, atom *Atom) error {
+	// get context atom renderer
+	if renderer, ok := r.ContextAtoms[atom.Name]; ok {
+		return renderer(ctx, w, loc, atom)
+	}
+
+	// get renderer
+	renderer, ok := r.Atoms[atom.Name]
+	if !ok {
+		return r.Fallback.renderAtom(ctx, w, loc, atom, markdownEscaper.Replace(atom.Text), markdownEscaper.Replace(fmt.Sprintf("[%s]", atom.Name)))
+	}
+
+	// call renderer
+	err := renderer(w, atom.Text, atom.Payload)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *MarkdownRenderer) content(stack []*markdownFrame) string {
+	// escape line starts that would be interpreted as block markers
+	content := markdownLineStart.ReplaceAllStringFunc(stack[0].buffer.String(), func(str string) string {
+		if last := str[len(str)-1]; last == '.' || last == ')' {
+			return str[:len(str)-1] + `\` + string(last)
+		}
+		indent := strings.TrimRight(str, "#+=-")
+		return indent + `\` + str[len(indent):]
+	})
+
+	// replace the first character of indents that would start a code block
+	content = markdownIndent.ReplaceAllStringFunc(content, func(str string) string {
+		if str[0] == '\t' {
+			return "&#9;" + str[1:]
+		}
+		return "&#32;" + str[1:]
+	})
+
+	return content
+}
+
+func (r *MarkdownRenderer) popFrame(stack []*markdownFrame, code int) ([]*markdownFrame, int) {
+	// pop frame
+	frame := stack[len(stack)-1]
+	stack = stack[:len(stack)-1]
+	if frame.markup.Tag == "code" {
+		code--
+	}
+
+	// get content and the preceding character
+	content := frame.buffer.String()
+	parent := &stack[len(stack)-1].buffer
+	prev, _ := utf8.DecodeLastRuneInString(parent.String())
+
+	// wrap content, markups within code spans are dropped
+	if code == 0 {
+		content = r.wrapMarkup(frame.markup, content, prev)
+	}
+
+	// escape a preceding exclamation mark that would turn a link into an image
+	if prev == '!' && strings.HasPrefix(content, "[") {
+		str := parent.String()
+		parent.Reset()
+		parent.WriteString(str[:len(str)-1] + `\!`)
+	}
+
+	// write content
+	parent.WriteString(content)
+
+	return stack, code
+}
+
+func (r *MarkdownRenderer) wrapMarkup(markup *Markup, content string, prev rune) string {
+	// wrap content
+	switch markup.Tag {
+	case "b", "strong":
+		return wrapDelimiter(content, "**", "strong", prev)
+	case "i", "em":
+		return wrapDelimiter(content, "*", "em", prev)
+	case "s":
+		return wrapDelimiter(content, "~~", "s", prev)
+	case "code":
+		return wrapCode(content)
+	case "a":
+		href, _ := markup.Attributes["href"].(string)
+		return fmt.Sprintf("[%s](%s)", content, markdownURLEscaper.Replace(href))
+	case "sub", "sup", "u":
+		return fmt.Sprintf("<%s>%s</%s>", markup.Tag, content, markup.Tag)
+	default:
+		return content
+	}
+}
+
+func isHeading(tag string) bool {
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	}
+	return false
+}
+
+func wrapDelimiter(content, delimiter, tag string, prev rune) string {
+	// delimiters must not be adjacent to whitespace, so surrounding
+	// whitespace is moved outside
+	trimmed := strings.TrimLeft(content, " \t")
+	leading := content[:len(content)-len(trimmed)]
+	inner := strings.TrimRight(trimmed, " \t")
+	trailing := trimmed[len(inner):]
+
+	// skip empty content
+	if inner == "" {
+		return content
+	}
+
+	// get surrounding characters
+	if leading != "" {
+		prev = ' '
+	}
+	first, _ := utf8.DecodeRuneInString(inner)
+	last, _ := utf8.DecodeLastRuneInString(inner)
+
+	// use inline HTML if the delimiter runs would merge with adjacent runs or
+	// could not open and close the emphasis. A closing run must be followed by
+	// whitespace or punctuation if it follows punctuation, as the following
+	// character is not known, such content is always wrapped using HTML.
+	char := rune(delimiter[0])
+	if prev == char || first == char || last == char ||
+		(isPunctuation(first) && prev != utf8.RuneError && !unicode.IsSpace(prev) && !isPunctuation(prev)) ||
+		isPunctuation(last) {
+		return leading + "<" + tag + ">" + inner + "</" + tag + ">" + trailing
+	}
+
+	return leading + delimiter + inner + delimiter + trailing
+}
+
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func wrapCode(content string) string {
+	// skip empty content
+	if content == "" {
+		return content
+	}
+
+	// the fence must be longer than any backtick run in the content
+	fence := "`"
+	for _, run := range markdownBackticks.FindAllString(content, -1) {
+		if len(run) >= len(fence) {
+			fence = run + "`"
+		}
+	}
+
+	// pad content that starts or ends with a backtick or space
+	if content[0] == '`' || content[len(content)-1] == '`' ||
+		(content[0] == ' ' && content[len(content)-1] == ' ' && strings.Trim(content, " ") != "") {
+		content = " " + content + " "
+	}
+
+	return fence + content + fence
+}
@@ -0,0 +1,182 @@
+package mobiledoc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownRenderer(t *testing.T) {
+	r := NewMarkdownRenderer()
+	r.Atoms["atom1"] = func(w *bufio.Writer, text string, payload Map) error {
+		_, err := w.WriteString(fmt.Sprintf("@%s1", text))
+		return err
+	}
+	r.Atoms["atom2"] = func(w *bufio.Writer, text string, payload Map) error {
+		_, err := w.WriteString(fmt.Sprintf("@%s2", text))
+		return err
+	}
+	r.Cards["card1"] = func(w *bufio.Writer, payload Map) error {
+		_, err := w.WriteString("```\ncard1\n```")
+		return err
+	}
+	r.Cards["card2"] = func(w *bufio.Writer, payload Map) error {
+		_, err := w.WriteString("```\ncard2\n```")
+		return err
+	}
+
+	out := "```\ncard1\n```\n\n" +
+		"foo**foo**<em>foofoo</em>*[foo](https://example.com)foo*\n\n" +
+		"@foo1<strong>@foo2@foo1</strong>\n\n" +
+		"![](https://example.com/foo.png)\n\n" +
+		"- foo**foo**\n- <strong>foo\\<foo\\></strong>\n\n" +
+		"1. bar*bar*\n2. <em>bar\\<bar\\></em>\n\n" +
+		"```\ncard2\n```"
+
+	buf := &bytes.Buffer{}
+	err := r.Render(buf, sampleDoc())
+	assert.NoError(t, err)
+	assert.Equal(t, out, buf.String())
+}
+
+func TestMarkdownRendererEscaping(t *testing.T) {
+	doc, err := NewBuilder().
+		Heading(1).Text("# Title *1*").
+		Heading(3).Text("Sub_title").
+		Paragraph().Text("1. not a list").
+		Paragraph().Text("- not a list\n+ still not\n2) nope").
+		Paragraph().Text("a <b> & [c](d) ~e~ \\ `f`").
+		Section("blockquote").Text("quote\nmore").
+		Section("aside").Text("aside ", Italic).Text("text", Strong).
+		Paragraph().
+		Text("code ").
+		Text("a*b`c", Code).
+		Text(" and ").
+		Text("`x`", Code).
+		Text(" and ").
+		Text("bold ", Bold, Code).
+		Text("link", Link("https://example.com/a (b)")).
+		Text(" ").
+		Text("2", Superscript).
+		Text("x", Subscript).
+		Text("del", Strikethrough).
+		Text("u", Underline).
+		Text("  ", Bold).
+		List("ol", "3. item", "second\nline").
+		Image("https://example.com/a b.png").
+		Build()
+	assert.NoError(t, err)
+
+	out := "# \\# Title \\*1\\*\n\n" +
+		"### Sub\\_title\n\n" +
+		"1\\. not a list\n\n" +
+		"\\- not a list\\\n\\+ still not\\\n2\\) nope\n\n" +
+		"a \\<b\\> \\& \\[c\\](d) \\~e\\~ \\\\ \\`f\\`\n\n" +
+		"> quote\\\n> more\n\n" +
+		"> *aside* **text**\n\n" +
+		"code ``a*b`c`` and `` `x` `` and <strong>`bold `</strong>[link](https://example.com/a%20%28b%29) <sup>2</sup><sub>x</sub>~~del~~<u>u</u>  \n\n" +
+		"1. 3\\. item\n2. second\\\n   line\n\n" +
+		"![](https://example.com/a%20b.png)"
+
+	buf := &bytes.Buffer{}
+	err = NewMarkdownRenderer().Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, out, buf.String())
+}
+
+func TestMarkdownRendererBlockStarts(t *testing.T) {
+	doc, err := NewBuilder().
+		Heading(2).Text("multi\nline ").Text("title", Code).
+		Paragraph().Text("   - not a list\n  # not a heading\n   1. nope").
+		Paragraph().Text("    not code\n\tnot code").
+		Build()
+	assert.NoError(t, err)
+
+	out := "## multi line `title`\n\n" +
+		"   \\- not a list\\\n  \\# not a heading\\\n   1\\. nope\n\n" +
+		"&#32;   not code\\\n&#9;not code"
+
+	buf := &bytes.Buffer{}
+	err = NewMarkdownRenderer().Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, out, buf.String())
+}
+
+func TestMarkdownRendererDelimiters(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("a").Text(`"foo"`, Bold).Text(" b").
+		Paragraph().Text("a ").Text(`"foo`, Bold).Text(" b").
+		Paragraph().Text("foo", Bold).Text("bar", Italic).
+		Paragraph().Text("foo", Bold, Italic).
+		Paragraph().Text("x").Text("y", Strikethrough).Text("z", Strikethrough, Bold).
+		Build()
+	assert.NoError(t, err)
+
+	out := "a<strong>\"foo\"</strong> b\n\n" +
+		"a **\"foo** b\n\n" +
+		"**foo**<em>bar</em>\n\n" +
+		"<strong>*foo*</strong>\n\n" +
+		"x<s>y**z**</s>"
+
+	buf := &bytes.Buffer{}
+	err = NewMarkdownRenderer().Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, out, buf.String())
+}
+
+func TestMarkdownRendererLinks(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("Look!").Text("here", Link("http://x")).Text("!").
+		Paragraph().Text("Look! ").Text("there", Link("http://y")).
+		Build()
+	assert.NoError(t, err)
+
+	out := "Look\\![here](http://x)!\n\n" +
+		"Look! [there](http://y)"
+
+	buf := &bytes.Buffer{}
+	err = NewMarkdownRenderer().Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, out, buf.String())
+
+	res, err := ConvertMarkdown(out)
+	assert.NoError(t, err)
+	assert.True(t, EqualWith(doc, res, EqualOptions{IgnoreSplits: true}))
+}
+
+func TestMarkdownRendererMissing(t *testing.T) {
+	err := NewMarkdownRenderer().Render(&bytes.Buffer{}, sampleDoc())
+	assert.Error(t, err)
+
+	doc := sampleDoc()
+	doc.Sections = doc.Sections[1:3]
+	err = NewMarkdownRenderer().Render(&bytes.Buffer{}, doc)
+	assert.Error(t, err)
+}
+
+func TestMarkdownRendererHooks(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("foo ").Text("a*b", Code).
+		Image("/foo.png").
+		Build()
+	assert.NoError(t, err)
+
+	r := NewMarkdownRenderer()
+	r.Image = func(w *bufio.Writer, section *Section) error {
+		_, err := w.WriteString(fmt.Sprintf("![image](%s)", section.Source))
+		return err
+	}
+	r.Text = func(w *bufio.Writer, text string) error {
+		_, err := w.WriteString(strings.ToUpper(text))
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	err = r.Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "FOO `a*b`\n\n![image](/foo.png)", buf.String())
+}
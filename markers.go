@@ -0,0 +1,79 @@
+package mobiledoc
+
+// balanceMarkers will set the open and closed markups of the specified
+// markers based on the list of markups that are active for each marker. The
+// order of the active markups defines the nesting for newly opened markups.
+func balanceMarkers(markers []Marker, active [][]*Markup) {
+	// prepare stack
+	var stack []*Markup
+
+	for i := range markers {
+		// open missing markups
+		var open []*Markup
+		for _, markup := range active[i] {
+			if !containsMarkup(stack, markup) {
+				open = append(open, markup)
+				stack = append(stack, markup)
+			}
+		}
+
+		// set open markups
+		markers[i].OpenMarkups = open
+
+		// get next active markups
+		var next []*Markup
+		if i < len(markers)-1 {
+			next = active[i+1]
+		}
+
+		// keep markups from the bottom of the stack that are still active
+		keep := 0
+		for keep < len(stack) && containsMarkup(next, stack[keep]) {
+			keep++
+		}
+
+		// close remaining markups
+		markers[i].ClosedMarkups = len(stack) - keep
+		stack = stack[:keep:keep]
+	}
+}
+
+func containsMarkup(list []*Markup, markup *Markup) bool {
+	// check existence
+	for _, item := range list {
+		if item == markup {
+			return true
+		}
+	}
+
+	return false
+}
+
+// activeMarkups will return the list of markups that are active for each of the
+// specified markers ordered from the bottom to the top of the stack.
+func activeMarkups(markers []Marker) [][]*Markup {
+	// prepare list
+	active := make([][]*Markup, len(markers))
+
+	// prepare stack
+	var stack []*Markup
+
+	for i, marker := range markers {
+		// open markups
+		stack = append(stack, marker.OpenMarkups...)
+
+		// set active markups
+		active[i] = append([]*Markup{}, stack...)
+
+		// close markups
+		closed := marker.ClosedMarkups
+		if closed > len(stack) {
+			closed = len(stack)
+		} else if closed < 0 {
+			closed = 0
+		}
+		stack = stack[:len(stack)-closed]
+	}
+
+	return active
+}
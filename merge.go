@@ -0,0 +1,441 @@
+package mobiledoc
+
+import (
+	"sort"
+	"strings"
+)
+
+// Conflict describes sections that have been changed differently in both
+// merged documents. The merged document contains our sections at the index.
+type Conflict struct {
+	// The index of our sections in the merged document.
+	Index int
+
+	// The conflicting sections of the base, our and their document.
+	Base   []Section
+	Ours   []Section
+	Theirs []Section
+}
+
+// Merge will perform a three-way merge of our and their document based on
+// their common base document. Changes to different sections are merged
+// automatically while changes to the same markup section or list item are
+// merged on a character level if they do not overlap. Remaining conflicts are
+// resolved by using our sections and reported.
+func Merge(base, ours, theirs Document) (Document, []Conflict) {
+	// prepare merger
+	m := merger{
+		markups: map[string]*Markup{},
+	}
+
+	// get section keys
+	baseKeys := sectionKeys(base.Sections)
+	ourKeys := sectionKeys(ours.Sections)
+	theirKeys := sectionKeys(theirs.Sections)
+
+	// merge sections
+	for _, chunk := range diff3(baseKeys, ourKeys, theirKeys) {
+		// get sections
+		b := base.Sections[chunk.base[0]:chunk.base[1]]
+		o := ours.Sections[chunk.ours[0]:chunk.ours[1]]
+		t := theirs.Sections[chunk.theirs[0]:chunk.theirs[1]]
+
+		// resolve chunk
+		switch chunk.resolve(baseKeys, ourKeys, theirKeys) {
+		case mergeOurs:
+			m.sections = append(m.sections, o...)
+			continue
+		case mergeTheirs:
+			m.sections = append(m.sections, t...)
+			continue
+		}
+
+		// merge sections individually
+		if len(b) == len(o) && len(b) == len(t) {
+			for i := range b {
+				bKey, oKey, tKey := baseKeys[chunk.base[0]+i], ourKeys[chunk.ours[0]+i], theirKeys[chunk.theirs[0]+i]
+				if oKey == tKey || bKey == tKey {
+					m.sections = append(m.sections, o[i])
+					continue
+				} else if bKey == oKey {
+					m.sections = append(m.sections, t[i])
+					continue
+				}
+				section, ok := m.mergeSection(b[i], o[i], t[i])
+				if !ok {
+					m.conflict(b[i:i+1], o[i:i+1], t[i:i+1])
+					continue
+				}
+				m.sections = append(m.sections, section)
+			}
+			continue
+		}
+
+		// add conflict
+		m.conflict(b, o, t)
+	}
+
+	// merge version
+	version, ok := mergeValue(base.Version, ours.Version, theirs.Version)
+	if !ok {
+		version = ours.Version
+	}
+
+	return extractSections(version, m.sections), m.conflicts
+}
+
+type merger struct {
+	sections  []Section
+	conflicts []Conflict
+	markups   map[string]*Markup
+}
+
+func (m *merger) conflict(base, ours, theirs []Section) {
+	// add conflict
+	m.conflicts = append(m.conflicts, Conflict{
+		Index:  len(m.sections),
+		Base:   base,
+		Ours:   ours,
+		Theirs: theirs,
+	})
+
+	// use our sections
+	m.sections = append(m.sections, ours...)
+}
+
+func (m *merger) mergeSection(base, ours, theirs Section) (Section, bool) {
+	// check types
+	if base.Type != ours.Type || base.Type != theirs.Type {
+		return Section{}, false
+	} else if base.Type != MarkupSection && base.Type != ListSection {
+		return Section{}, false
+	}
+
+	// merge tag
+	tag, ok := mergeValue(base.Tag, ours.Tag, theirs.Tag)
+	if !ok {
+		return Section{}, false
+	}
+
+	// merge attributes
+	attributes := ours.Attributes
+	key, ok := mergeValue(valueKey(base.Attributes), valueKey(ours.Attributes), valueKey(theirs.Attributes))
+	if !ok {
+		return Section{}, false
+	} else if key != valueKey(ours.Attributes) {
+		attributes = theirs.Attributes
+	}
+
+	// prepare section
+	section := Section{
+		Type:       base.Type,
+		Tag:        tag,
+		Attributes: attributes,
+	}
+
+	// merge markers
+	if base.Type == MarkupSection {
+		section.Markers, ok = m.mergeMarkers(base.Markers, ours.Markers, theirs.Markers)
+		return section, ok
+	}
+
+	// get item keys
+	baseKeys := itemKeys(base.Items)
+	ourKeys := itemKeys(ours.Items)
+	theirKeys := itemKeys(theirs.Items)
+
+	// merge items
+	section.Items = [][]Marker{}
+	for _, chunk := range diff3(baseKeys, ourKeys, theirKeys) {
+		// get items
+		b := base.Items[chunk.base[0]:chunk.base[1]]
+		o := ours.Items[chunk.ours[0]:chunk.ours[1]]
+		t := theirs.Items[chunk.theirs[0]:chunk.theirs[1]]
+
+		// resolve chunk
+		switch chunk.resolve(baseKeys, ourKeys, theirKeys) {
+		case mergeOurs:
+			section.Items = append(section.Items, o...)
+			continue
+		case mergeTheirs:
+			section.Items = append(section.Items, t...)
+			continue
+		}
+
+		// merge items individually
+		if len(b) != len(o) || len(b) != len(t) {
+			return Section{}, false
+		}
+		for i := range b {
+			bKey, oKey, tKey := baseKeys[chunk.base[0]+i], ourKeys[chunk.ours[0]+i], theirKeys[chunk.theirs[0]+i]
+			if oKey == tKey || bKey == tKey {
+				section.Items = append(section.Items, o[i])
+				continue
+			} else if bKey == oKey {
+				section.Items = append(section.Items, t[i])
+				continue
+			}
+			item, ok := m.mergeMarkers(b[i], o[i], t[i])
+			if !ok {
+				return Section{}, false
+			}
+			section.Items = append(section.Items, item)
+		}
+	}
+
+	return section, true
+}
+
+func (m *merger) mergeMarkers(base, ours, theirs []Marker) ([]Marker, bool) {
+	// get tokens
+	baseTokens := m.tokens(base)
+	ourTokens := m.tokens(ours)
+	theirTokens := m.tokens(theirs)
+
+	// get keys
+	baseKeys := tokenKeys(baseTokens)
+	ourKeys := tokenKeys(ourTokens)
+	theirKeys := tokenKeys(theirTokens)
+
+	// merge tokens
+	var tokens []mergeToken
+	for _, chunk := range diff3(baseKeys, ourKeys, theirKeys) {
+		// merge markups of stable tokens
+		if chunk.stable {
+			tokens = append(tokens, m.mergeToken(baseTokens[chunk.base[0]], ourTokens[chunk.ours[0]], theirTokens[chunk.theirs[0]]))
+			continue
+		}
+
+		// resolve chunk
+		switch chunk.resolve(baseKeys, ourKeys, theirKeys) {
+		case mergeOurs:
+			tokens = append(tokens, ourTokens[chunk.ours[0]:chunk.ours[1]]...)
+		case mergeTheirs:
+			tokens = append(tokens, theirTokens[chunk.theirs[0]:chunk.theirs[1]]...)
+		default:
+			return nil, false
+		}
+	}
+
+	// build markers
+	markers := make([]Marker, 0, len(tokens))
+	var active [][]*Markup
+	for i, token := range tokens {
+		// add atom marker
+		if token.atom != nil {
+			markers = append(markers, Marker{Type: AtomMarker, Atom: token.atom})
+			active = append(active, token.markups)
+			continue
+		}
+
+		// merge with previous text marker
+		if n := len(markers); n > 0 && markers[n-1].Type == TextMarker && tokens[i-1].set == token.set {
+			markers[n-1].Text += string(token.char)
+			continue
+		}
+
+		// add text marker
+		markers = append(markers, Marker{Type: TextMarker, Text: string(token.char)})
+		active = append(active, token.markups)
+	}
+
+	// balance markers
+	balanceMarkers(markers, active)
+
+	return markers, true
+}
+
+func (m *merger) mergeToken(base, ours, theirs mergeToken) mergeToken {
+	// use our token if markups are unchanged by them
+	if base.set == theirs.set {
+		return ours
+	} else if base.set == ours.set {
+		return theirs
+	}
+
+	// collect markups that are kept or added by either side
+	token := ours
+	token.markups = nil
+	var keys []string
+	for _, markup := range append(append([]*Markup{}, ours.markups...), theirs.markups...) {
+		key := markupKey(*markup)
+		if contains(keys, key) {
+			continue
+		}
+		inBase := containsMarkup(base.markups, markup)
+		inOurs := containsMarkup(ours.markups, markup)
+		inTheirs := containsMarkup(theirs.markups, markup)
+		if inBase && (!inOurs || !inTheirs) {
+			continue
+		}
+		keys = append(keys, key)
+		token.markups = append(token.markups, markup)
+	}
+
+	// sort markups
+	sort.Slice(token.markups, func(i, j int) bool {
+		return markupKey(*token.markups[i]) < markupKey(*token.markups[j])
+	})
+	sort.Strings(keys)
+	token.set = strings.Join(keys, "\x00")
+
+	return token
+}
+
+type mergeToken struct {
+	key     string
+	set     string
+	char    rune
+	markups []*Markup
+	atom    *Atom
+}
+
+func (m *merger) tokens(markers []Marker) []mergeToken {
+	// get content
+	c := contentOf(markers)
+
+	// create tokens
+	tokens := make([]mergeToken, len(c.text))
+	for i, char := range c.text {
+		// get markups
+		markups := make([]*Markup, 0, len(c.markups[i]))
+		for _, key := range c.markups[i] {
+			markup, ok := m.markups[key]
+			if !ok {
+				value := c.values[key]
+				markup = &value
+				m.markups[key] = markup
+			}
+			markups = append(markups, markup)
+		}
+
+		// prepare token
+		tokens[i] = mergeToken{
+			key:     string(char),
+			set:     strings.Join(c.markups[i], "\x00"),
+			char:    char,
+			markups: markups,
+		}
+
+		// add atom
+		if atom, ok := c.atoms[i]; ok {
+			tokens[i].atom = &atom
+			tokens[i].key += "\x00" + valueKey(atom.Name, atom.Text, atom.Payload)
+		}
+	}
+
+	return tokens
+}
+
+func tokenKeys(tokens []mergeToken) []string {
+	// collect keys
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		keys[i] = token.key
+	}
+
+	return keys
+}
+
+type mergeResult int
+
+const (
+	mergeConflict mergeResult = iota
+	mergeOurs
+	mergeTheirs
+)
+
+type mergeChunk struct {
+	stable bool
+	base   [2]int
+	ours   [2]int
+	theirs [2]int
+}
+
+func (c mergeChunk) resolve(base, ours, theirs []string) mergeResult {
+	// get keys
+	b := strings.Join(base[c.base[0]:c.base[1]], "\x01")
+	o := strings.Join(ours[c.ours[0]:c.ours[1]], "\x01")
+	t := strings.Join(theirs[c.theirs[0]:c.theirs[1]], "\x01")
+
+	// resolve chunk
+	switch {
+	case c.stable || o == t || b == t:
+		return mergeOurs
+	case b == o:
+		return mergeTheirs
+	default:
+		return mergeConflict
+	}
+}
+
+// diff3 will split the three lists into chunks that are either stable or
+// changed in at least one of the derived lists.
+func diff3(base, ours, theirs []string) []mergeChunk {
+	// compute matches
+	ourMatches := map[int]int{}
+	for _, match := range lcs(len(base), len(ours), func(i, j int) bool {
+		return base[i] == ours[j]
+	}) {
+		ourMatches[match[0]] = match[1]
+	}
+	theirMatches := map[int]int{}
+	for _, match := range lcs(len(base), len(theirs), func(i, j int) bool {
+		return base[i] == theirs[j]
+	}) {
+		theirMatches[match[0]] = match[1]
+	}
+
+	// prepare chunks
+	var chunks []mergeChunk
+
+	// walk base
+	b, o, t := 0, 0, 0
+	for k := 0; k <= len(base); k++ {
+		// get matches
+		oi, ok1 := ourMatches[k]
+		ti, ok2 := theirMatches[k]
+		if k < len(base) && (!ok1 || !ok2) {
+			continue
+		}
+		if k == len(base) {
+			oi, ti = len(ours), len(theirs)
+		}
+
+		// add unstable chunk
+		if b < k || o < oi || t < ti {
+			chunks = append(chunks, mergeChunk{
+				base:   [2]int{b, k},
+				ours:   [2]int{o, oi},
+				theirs: [2]int{t, ti},
+			})
+		}
+
+		// add stable chunk
+		if k < len(base) {
+			chunks = append(chunks, mergeChunk{
+				stable: true,
+				base:   [2]int{k, k + 1},
+				ours:   [2]int{oi, oi + 1},
+				theirs: [2]int{ti, ti + 1},
+			})
+		}
+
+		// advance
+		b, o, t = k+1, oi+1, ti+1
+	}
+
+	return chunks
+}
+
+func mergeValue(base, ours, theirs string) (string, bool) {
+	// merge value
+	switch {
+	case ours == theirs || base == theirs:
+		return ours, true
+	case base == ours:
+		return theirs, true
+	default:
+		return "", false
+	}
+}
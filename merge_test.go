@@ -0,0 +1,101 @@
+package mobiledoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	base, err := NewBuilder().
+		Heading(1).Text("Title").
+		Paragraph().Text("Hello world").
+		List("ul", "one", "two").
+		Paragraph().Text("Footer").
+		Build()
+	require.NoError(t, err)
+
+	ours, err := NewBuilder().
+		Heading(2).Text("Title").
+		Paragraph().Text("Hello big world").
+		List("ul", "one", "two", "three").
+		Paragraph().Text("Footer").
+		Build()
+	require.NoError(t, err)
+
+	theirs, err := NewBuilder().
+		Heading(1).Text("New Title").
+		Paragraph().Text("Hello world", Bold).Text("!").
+		List("ul", "zero", "one", "two").
+		Card("card", Map{"foo": "bar"}).
+		Build()
+	require.NoError(t, err)
+
+	merged, conflicts := Merge(base, ours, theirs)
+	assert.Empty(t, conflicts)
+
+	expected, err := NewBuilder().
+		Heading(2).Text("New Title").
+		Paragraph().Text("Hello ", Bold).Text("big ").Text("world", Bold).Text("!").
+		List("ul", "zero", "one", "two", "three").
+		Card("card", Map{"foo": "bar"}).
+		Build()
+	require.NoError(t, err)
+	assert.True(t, Equal(expected, merged))
+	assert.NoError(t, NewFormatValidator().Validate(merged))
+}
+
+func TestMergeConflicts(t *testing.T) {
+	base, err := NewBuilder().
+		Paragraph().Text("Hello world").
+		Paragraph().Text("Unchanged").
+		Card("card", Map{"foo": 1}).
+		Build()
+	require.NoError(t, err)
+
+	ours, err := NewBuilder().
+		Paragraph().Text("Hello there").
+		Paragraph().Text("Unchanged").
+		Card("card", Map{"foo": 2}).
+		Build()
+	require.NoError(t, err)
+
+	theirs, err := NewBuilder().
+		Paragraph().Text("Hello you").
+		Paragraph().Text("Unchanged!").
+		Card("card", Map{"foo": 3}).
+		Build()
+	require.NoError(t, err)
+
+	merged, conflicts := Merge(base, ours, theirs)
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, 0, conflicts[0].Index)
+	assert.Equal(t, base.Sections[:1], conflicts[0].Base)
+	assert.Equal(t, ours.Sections[:1], conflicts[0].Ours)
+	assert.Equal(t, theirs.Sections[:1], conflicts[0].Theirs)
+	assert.Equal(t, 2, conflicts[1].Index)
+
+	expected, err := NewBuilder().
+		Paragraph().Text("Hello there").
+		Paragraph().Text("Unchanged!").
+		Card("card", Map{"foo": 2}).
+		Build()
+	require.NoError(t, err)
+	assert.True(t, Equal(expected, merged))
+}
+
+func TestMergeIdentical(t *testing.T) {
+	doc := sampleDoc()
+
+	merged, conflicts := Merge(doc, doc, doc)
+	assert.Empty(t, conflicts)
+	assert.True(t, Equal(doc, merged))
+
+	other := doc.Clone()
+	other.Sections = other.Sections[1:]
+
+	merged, conflicts = Merge(doc, other, doc)
+	assert.Empty(t, conflicts)
+	assert.True(t, Equal(other, merged))
+}
@@ -0,0 +1,10 @@
+package mobiledoc
+
+// Normalize returns a compacted copy of the document. Duplicate markups, atoms
+// and cards are merged and definitions that are not referenced by any section
+// are removed. Empty text markers are dropped, adjacent text markers with the
+// same markups are merged and the open and closed markups of all markers are
+// recomputed so that longer running markups enclose shorter ones.
+func Normalize(doc Document) Document {
+	return canonicalize(doc, true)
+}
@@ -0,0 +1,102 @@
+package mobiledoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	doc := Document{
+		Version: Version,
+		Markups: []Markup{{Tag: "b"}, {Tag: "i"}, {Tag: "b"}, {Tag: "u"}},
+		Atoms: []Atom{
+			{Name: "atom", Text: "a", Payload: Map{"n": 1}},
+			{Name: "atom", Text: "a", Payload: Map{"n": 1}},
+			{Name: "orphan", Payload: Map{}},
+		},
+		Cards: []Card{
+			{Name: "orphan", Payload: Map{}},
+			{Name: "card", Payload: Map{"foo": "bar"}},
+		},
+	}
+	doc.Sections = []Section{
+		{Type: CardSection, Card: &doc.Cards[1]},
+		{Type: MarkupSection, Tag: "p", Markers: []Marker{
+			{Type: TextMarker, OpenMarkups: []*Markup{&doc.Markups[1]}, ClosedMarkups: 1, Text: "a"},
+			{Type: TextMarker, OpenMarkups: []*Markup{&doc.Markups[0]}, ClosedMarkups: 1, Text: "b"},
+			{Type: TextMarker, OpenMarkups: []*Markup{&doc.Markups[2]}, ClosedMarkups: 1, Text: "c"},
+			{Type: TextMarker, Text: ""},
+			{Type: AtomMarker, Atom: &doc.Atoms[0]},
+			{Type: AtomMarker, Atom: &doc.Atoms[1]},
+			{Type: TextMarker, Text: "d"},
+			{Type: TextMarker, Text: "e"},
+		}},
+		{Type: ListSection, Tag: "ul", Items: [][]Marker{
+			{
+				{Type: TextMarker, OpenMarkups: []*Markup{&doc.Markups[2]}, Text: "f"},
+				{Type: TextMarker, ClosedMarkups: 1, Text: "g"},
+			},
+			{},
+		}},
+		{Type: CardSection, Card: &doc.Cards[1]},
+	}
+
+	out := Normalize(doc)
+
+	expected := Document{
+		Version: Version,
+		Markups: []Markup{{Tag: "i"}, {Tag: "b"}},
+		Atoms:   []Atom{{Name: "atom", Text: "a", Payload: Map{"n": 1}}},
+		Cards:   []Card{{Name: "card", Payload: Map{"foo": "bar"}}},
+	}
+	expected.Sections = []Section{
+		{Type: CardSection, Card: &expected.Cards[0]},
+		{Type: MarkupSection, Tag: "p", Markers: []Marker{
+			{Type: TextMarker, OpenMarkups: []*Markup{&expected.Markups[0]}, ClosedMarkups: 1, Text: "a"},
+			{Type: TextMarker, OpenMarkups: []*Markup{&expected.Markups[1]}, ClosedMarkups: 1, Text: "bc"},
+			{Type: AtomMarker, Atom: &expected.Atoms[0]},
+			{Type: AtomMarker, Atom: &expected.Atoms[0]},
+			{Type: TextMarker, Text: "de"},
+		}},
+		{Type: ListSection, Tag: "ul", Items: [][]Marker{
+			{
+				{Type: TextMarker, OpenMarkups: []*Markup{&expected.Markups[1]}, ClosedMarkups: 1, Text: "fg"},
+			},
+			{},
+		}},
+		{Type: CardSection, Card: &expected.Cards[0]},
+	}
+
+	assert.Equal(t, expected, out)
+	assert.True(t, out.Sections[1].Markers[2].Atom == &out.Atoms[0])
+	assert.True(t, out.Sections[3].Card == &out.Cards[0])
+	assert.NoError(t, NewFormatValidator().Validate(out))
+	assert.True(t, EqualWith(doc, out, EqualOptions{IgnoreSplits: true}))
+	assert.Equal(t, out, Normalize(out))
+
+	_, err := Compile(out)
+	assert.NoError(t, err)
+}
+
+func TestNormalizeNesting(t *testing.T) {
+	doc := Document{
+		Version: Version,
+		Markups: []Markup{{Tag: "i"}, {Tag: "b"}},
+	}
+	doc.Sections = []Section{
+		{Type: MarkupSection, Tag: "p", Markers: []Marker{
+			{Type: TextMarker, OpenMarkups: []*Markup{&doc.Markups[0], &doc.Markups[1]}, Text: "a"},
+			{Type: TextMarker, ClosedMarkups: 2, OpenMarkups: nil, Text: "b"},
+			{Type: TextMarker, OpenMarkups: []*Markup{&doc.Markups[1]}, Text: "c"},
+			{Type: TextMarker, ClosedMarkups: 1, Text: "d"},
+		}},
+	}
+
+	out := Normalize(doc)
+	assert.Equal(t, []Markup{{Tag: "b"}, {Tag: "i"}}, out.Markups)
+	assert.Equal(t, []Marker{
+		{Type: TextMarker, OpenMarkups: []*Markup{&out.Markups[0], &out.Markups[1]}, ClosedMarkups: 1, Text: "ab"},
+		{Type: TextMarker, ClosedMarkups: 1, Text: "cd"},
+	}, out.Sections[0].Markers)
+}
@@ -1,16 +1,25 @@
 package mobiledoc
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
-// Parse will parse the specified raw structure into a document.
+// Parse will parse the specified raw structure into a document. Problems are
+// returned as a *ParseError.
 func Parse(doc Map) (Document, error) {
 	// prepare document
 	d := Document{}
 
 	// get version
-	version, ok := doc["version"].(string)
-	if !ok {
-		return d, fmt.Errorf("invalid version")
+	value, ok := doc["version"]
+	version, ok2 := value.(string)
+	if !ok || !ok2 {
+		actual := describe(value)
+		if !ok {
+			actual = "missing"
+		}
+		return d, parseError(ErrInvalidVersion, "string", actual, "version")
 	}
 
 	// set version
@@ -21,24 +30,24 @@ func Parse(doc Map) (Document, error) {
 		// coerce value
 		markups, ok := toList(value)
 		if !ok {
-			return d, fmt.Errorf("invalid markups definition")
+			return d, parseError(ErrInvalidValue, "list", describe(value), "markups")
 		}
 
 		// allocate markups
 		d.Markups = make([]Markup, 0, len(markups))
 
 		// parse markups
-		for _, value := range markups {
+		for i, value := range markups {
 			// coerce value
 			markup, ok := toList(value)
 			if !ok {
-				return d, fmt.Errorf("invalid markups definition")
+				return d, parseError(ErrInvalidValue, "list", describe(value), "markups", i)
 			}
 
 			// parse markup
 			m, err := parseMarkup(markup)
 			if err != nil {
-				return d, err
+				return d, prefixError(err, "markups", i)
 			}
 
 			// add markup
@@ -51,24 +60,24 @@ func Parse(doc Map) (Document, error) {
 		// coerce value
 		atoms, ok := toList(value)
 		if !ok {
-			return d, fmt.Errorf("invalid atoms definition")
+			return d, parseError(ErrInvalidValue, "list", describe(value), "atoms")
 		}
 
 		// allocate atoms
 		d.Atoms = make([]Atom, 0, len(atoms))
 
 		// parse atoms
-		for _, value := range atoms {
+		for i, value := range atoms {
 			// coerce value
 			atom, ok := toList(value)
 			if !ok {
-				return d, fmt.Errorf("invalid atoms definition")
+				return d, parseError(ErrInvalidValue, "list", describe(value), "atoms", i)
 			}
 
 			// parse atom
 			a, err := parseAtom(atom)
 			if err != nil {
-				return d, err
+				return d, prefixError(err, "atoms", i)
 			}
 
 			// add atom
@@ -81,24 +90,24 @@ func Parse(doc Map) (Document, error) {
 		// coerce value
 		cards, ok := toList(value)
 		if !ok {
-			return d, fmt.Errorf("invalid cards definition")
+			return d, parseError(ErrInvalidValue, "list", describe(value), "cards")
 		}
 
 		// allocate cards
 		d.Cards = make([]Card, 0, len(cards))
 
 		// parse cards
-		for _, value := range cards {
+		for i, value := range cards {
 			// coerce value
 			card, ok := toList(value)
 			if !ok {
-				return d, fmt.Errorf("invalid cards definition")
+				return d, parseError(ErrInvalidValue, "list", describe(value), "cards", i)
 			}
 
 			// parse card
 			c, err := parseCard(card)
 			if err != nil {
-				return d, err
+				return d, prefixError(err, "cards", i)
 			}
 
 			// add card
@@ -111,24 +120,24 @@ func Parse(doc Map) (Document, error) {
 		// coerce value
 		sections, ok := toList(value)
 		if !ok {
-			return d, fmt.Errorf("invalid sections definition")
+			return d, parseError(ErrInvalidValue, "list", describe(value), "sections")
 		}
 
 		// allocate sections
 		d.Sections = make([]Section, 0, len(sections))
 
 		// parse sections
-		for _, value := range sections {
+		for i, value := range sections {
 			// coerce value
 			section, ok := toList(value)
 			if !ok {
-				return d, fmt.Errorf("invalid sections definition")
+				return d, parseError(ErrInvalidValue, "list", describe(value), "sections", i)
 			}
 
 			// parse section
-			s, err := parseSection(section, d.Markups, d.Atoms, d.Cards)
+			s, err := parseSection(section, d.Markups, d.Atoms, d.Cards, version == Version032)
 			if err != nil {
-				return d, err
+				return d, prefixError(err, "sections", i)
 			}
 
 			// add section
@@ -145,13 +154,13 @@ func parseMarkup(markup List) (Markup, error) {
 
 	// check length
 	if len(markup) == 0 || len(markup) > 2 {
-		return m, fmt.Errorf("invalid markup definition")
+		return m, parseError(ErrInvalidLength, "1 or 2 elements", describeLength(markup))
 	}
 
 	// get tag
 	tag, ok := markup[0].(string)
 	if !ok || len(tag) == 0 {
-		return m, fmt.Errorf("invalid markup tag")
+		return m, parseError(ErrInvalidValue, "non-empty string", describe(markup[0]), 0)
 	}
 
 	// set tag
@@ -165,27 +174,43 @@ func parseMarkup(markup List) (Markup, error) {
 	// get attributes
 	attributes, ok := toList(markup[1])
 	if !ok {
-		return m, fmt.Errorf("invalid markup attributes")
-	} else if len(attributes)%2 != 0 {
-		return m, fmt.Errorf("invalid markup attributes")
+		return m, parseError(ErrInvalidValue, "list", describe(markup[1]), 1)
+	}
+
+	// parse attributes
+	var err error
+	m.Attributes, _, err = parseAttributes(attributes)
+	if err != nil {
+		return m, prefixError(err, 1)
+	}
+
+	return m, nil
+}
+
+func parseAttributes(attributes List) (Map, []string, error) {
+	// check length
+	if len(attributes)%2 != 0 {
+		return nil, nil, parseError(ErrInvalidLength, "even number of elements", describeLength(attributes))
 	}
 
-	// allocate attributes
-	m.Attributes = make(Map, len(attributes)/2)
+	// allocate attributes and order
+	m := make(Map, len(attributes)/2)
+	order := make([]string, 0, len(attributes)/2)
 
 	// parse attributes
 	for i := 0; i < len(attributes); i += 2 {
 		// get name
 		name, ok := attributes[i].(string)
 		if !ok {
-			return m, fmt.Errorf("invalid markup attributes key")
+			return nil, nil, parseError(ErrInvalidValue, "string", describe(attributes[i]), i)
 		}
 
 		// set attribute
-		m.Attributes[name] = attributes[i+1]
+		m[name] = attributes[i+1]
+		order = append(order, name)
 	}
 
-	return m, nil
+	return m, order, nil
 }
 
 func parseAtom(atom List) (Atom, error) {
@@ -194,13 +219,13 @@ func parseAtom(atom List) (Atom, error) {
 
 	// check length
 	if len(atom) != 3 {
-		return a, fmt.Errorf("invalid atom definition")
+		return a, parseError(ErrInvalidLength, "3 elements", describeLength(atom))
 	}
 
 	// get name
 	name, ok := atom[0].(string)
 	if !ok {
-		return a, fmt.Errorf("invalid atom name")
+		return a, parseError(ErrInvalidValue, "string", describe(atom[0]), 0)
 	}
 
 	// set name
@@ -209,7 +234,7 @@ func parseAtom(atom List) (Atom, error) {
 	// get text
 	text, ok := atom[1].(string)
 	if !ok {
-		return a, fmt.Errorf("invalid atom text")
+		return a, parseError(ErrInvalidValue, "string", describe(atom[1]), 1)
 	}
 
 	// set text
@@ -218,7 +243,7 @@ func parseAtom(atom List) (Atom, error) {
 	// get payload
 	payload, ok := toMap(atom[2])
 	if !ok {
-		return a, fmt.Errorf("invalid atom payload")
+		return a, parseError(ErrInvalidValue, "map", describe(atom[2]), 2)
 	}
 
 	// set payload
@@ -233,13 +258,13 @@ func parseCard(card List) (Card, error) {
 
 	// check length
 	if len(card) != 2 {
-		return c, fmt.Errorf("invalid card definition")
+		return c, parseError(ErrInvalidLength, "2 elements", describeLength(card))
 	}
 
 	// get name
 	name, ok := card[0].(string)
 	if !ok {
-		return c, fmt.Errorf("invalid card name")
+		return c, parseError(ErrInvalidValue, "string", describe(card[0]), 0)
 	}
 
 	// set name
@@ -248,7 +273,7 @@ func parseCard(card List) (Card, error) {
 	// get payload
 	payload, ok := toMap(card[1])
 	if !ok {
-		return c, fmt.Errorf("invalid card payload")
+		return c, parseError(ErrInvalidValue, "map", describe(card[1]), 1)
 	}
 
 	// set payload
@@ -257,49 +282,49 @@ func parseCard(card List) (Card, error) {
 	return c, nil
 }
 
-func parseSection(section List, markups []Markup, atoms []Atom, cards []Card) (Section, error) {
+func parseSection(section List, markups []Markup, atoms []Atom, cards []Card, attributes bool) (Section, error) {
 	// prepare section
 	s := Section{}
 
 	// check length
 	if len(section) == 0 {
-		return s, fmt.Errorf("invalid section definition")
+		return s, parseError(ErrInvalidLength, "at least 1 element", describeLength(section))
 	}
 
 	// get section type
 	typ, ok := toInt(section[0])
 	if !ok {
-		return s, fmt.Errorf("invalid section type")
+		return s, parseError(ErrInvalidValue, "number", describe(section[0]), 0)
 	}
 
 	// parse section
 	switch SectionType(typ) {
 	case MarkupSection:
-		return parseMarkupSection(section, markups, atoms)
+		return parseMarkupSection(section, markups, atoms, attributes)
 	case ImageSection:
 		return parseImageSection(section)
 	case ListSection:
-		return parseListSection(section, markups, atoms)
+		return parseListSection(section, markups, atoms, attributes)
 	case CardSection:
 		return parseCardSection(section, cards)
 	default:
-		return s, fmt.Errorf("invalid section type")
+		return s, parseError(ErrInvalidSectionType, "section type", strconv.Itoa(typ), 0)
 	}
 }
 
-func parseMarkupSection(section List, markups []Markup, atoms []Atom) (Section, error) {
+func parseMarkupSection(section List, markups []Markup, atoms []Atom, attributes bool) (Section, error) {
 	// prepare section
 	s := Section{Type: MarkupSection}
 
 	// check length
-	if len(section) != 3 {
-		return s, fmt.Errorf("invalid markup section definition")
+	if len(section) != 3 && (!attributes || len(section) != 4) {
+		return s, parseError(ErrInvalidLength, sectionLength(attributes), describeLength(section))
 	}
 
 	// get tag
 	tag, ok := section[1].(string)
 	if !ok {
-		return s, fmt.Errorf("invalid markup section tag")
+		return s, parseError(ErrInvalidValue, "string", describe(section[1]), 1)
 	}
 
 	// set tag
@@ -308,7 +333,7 @@ func parseMarkupSection(section List, markups []Markup, atoms []Atom) (Section,
 	// get items
 	items, ok := toList(section[2])
 	if !ok {
-		return s, fmt.Errorf("invalid markup section items")
+		return s, parseError(ErrInvalidValue, "list", describe(section[2]), 2)
 	}
 
 	// prepare open markup counter
@@ -322,23 +347,31 @@ func parseMarkupSection(section List, markups []Markup, atoms []Atom) (Section,
 	s.Markers = make([]Marker, 0, len(items))
 
 	// parse markers
-	for _, value := range items {
+	for i, value := range items {
 		// coerce value
 		marker, ok := toList(value)
 		if !ok {
-			return s, fmt.Errorf("invalid markup section marker definition")
+			return s, parseError(ErrInvalidValue, "list", describe(value), 2, i)
 		}
 
 		// parse marker
 		m, openMarkups, err = parseMarker(marker, markups, atoms, openMarkups)
 		if err != nil {
-			return s, err
+			return s, prefixError(err, 2, i)
 		}
 
 		// add marker
 		s.Markers = append(s.Markers, m)
 	}
 
+	// parse attributes
+	if len(section) == 4 {
+		s.Attributes, err = parseSectionAttributes(section[3])
+		if err != nil {
+			return s, prefixError(err, 3)
+		}
+	}
+
 	return s, nil
 }
 
@@ -348,13 +381,13 @@ func parseImageSection(image List) (Section, error) {
 
 	// check length
 	if len(image) != 2 {
-		return s, fmt.Errorf("invalid image section definition")
+		return s, parseError(ErrInvalidLength, "2 elements", describeLength(image))
 	}
 
 	// get source
 	source, ok := image[1].(string)
 	if !ok {
-		return s, fmt.Errorf("invalid image section source")
+		return s, parseError(ErrInvalidValue, "string", describe(image[1]), 1)
 	}
 
 	// set source
@@ -363,19 +396,19 @@ func parseImageSection(image List) (Section, error) {
 	return s, nil
 }
 
-func parseListSection(list List, markups []Markup, atoms []Atom) (Section, error) {
+func parseListSection(list List, markups []Markup, atoms []Atom, attributes bool) (Section, error) {
 	// prepare section
 	s := Section{Type: ListSection}
 
 	// check length
-	if len(list) != 3 {
-		return s, fmt.Errorf("invalid list section definition")
+	if len(list) != 3 && (!attributes || len(list) != 4) {
+		return s, parseError(ErrInvalidLength, sectionLength(attributes), describeLength(list))
 	}
 
 	// get tag
 	tag, ok := list[1].(string)
 	if !ok {
-		return s, fmt.Errorf("invalid list section tag")
+		return s, parseError(ErrInvalidValue, "string", describe(list[1]), 1)
 	}
 
 	// set tag
@@ -384,18 +417,18 @@ func parseListSection(list List, markups []Markup, atoms []Atom) (Section, error
 	// get items
 	items, ok := toList(list[2])
 	if !ok {
-		return s, fmt.Errorf("invalid list section items")
+		return s, parseError(ErrInvalidValue, "list", describe(list[2]), 2)
 	}
 
 	// allocate items
 	s.Items = make([][]Marker, 0, len(items))
 
 	// parse items
-	for _, value := range items {
+	for i, value := range items {
 		// coerce value
 		item, ok := toList(value)
 		if !ok {
-			return s, fmt.Errorf("invalid list section item")
+			return s, parseError(ErrInvalidValue, "list", describe(value), 2, i)
 		}
 
 		// prepare open markup counter
@@ -409,17 +442,17 @@ func parseListSection(list List, markups []Markup, atoms []Atom) (Section, error
 		list := make([]Marker, 0, len(item))
 
 		// parse markers
-		for _, value := range item {
+		for j, value := range item {
 			// coerce value
 			marker, ok := toList(value)
 			if !ok {
-				return s, fmt.Errorf("invalid list section item marker")
+				return s, parseError(ErrInvalidValue, "list", describe(value), 2, i, j)
 			}
 
 			// parse marker
 			m, openMarkups, err = parseMarker(marker, markups, atoms, openMarkups)
 			if err != nil {
-				return s, err
+				return s, prefixError(err, 2, i, j)
 			}
 
 			// add marker
@@ -430,27 +463,66 @@ func parseListSection(list List, markups []Markup, atoms []Atom) (Section, error
 		s.Items = append(s.Items, list)
 	}
 
+	// parse attributes
+	if len(list) == 4 {
+		attributes, err := parseSectionAttributes(list[3])
+		if err != nil {
+			return s, prefixError(err, 3)
+		}
+
+		// set attributes
+		s.Attributes = attributes
+	}
+
 	return s, nil
 }
 
+func parseSectionAttributes(value interface{}) (Map, error) {
+	// coerce value
+	list, ok := toList(value)
+	if !ok {
+		return nil, parseError(ErrInvalidValue, "list", describe(value))
+	}
+
+	// return if empty
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	// parse attributes
+	attributes, _, err := parseAttributes(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+func sectionLength(attributes bool) string {
+	if attributes {
+		return "3 or 4 elements"
+	}
+	return "3 elements"
+}
+
 func parseCardSection(card List, cards []Card) (Section, error) {
 	// prepare card
 	s := Section{Type: CardSection}
 
 	// check length
 	if len(card) != 2 {
-		return s, fmt.Errorf("invalid card section definition")
+		return s, parseError(ErrInvalidLength, "2 elements", describeLength(card))
 	}
 
 	// get index
 	index, ok := toInt(card[1])
 	if !ok {
-		return s, fmt.Errorf("invalid card section index")
+		return s, parseError(ErrInvalidValue, "number", describe(card[1]), 1)
 	}
 
 	// check index
-	if index >= len(cards) {
-		return s, fmt.Errorf("invalid card section index")
+	if index < 0 || index >= len(cards) {
+		return s, parseError(ErrInvalidIndex, indexRange("card", len(cards)), strconv.Itoa(index), 1)
 	}
 
 	// set card
@@ -460,33 +532,34 @@ func parseCardSection(card List, cards []Card) (Section, error) {
 }
 
 func parseMarker(marker List, markups []Markup, atoms []Atom, openMarkups int) (Marker, int, error) {
-	// prepare marker
-	m := Marker{}
-
 	// check length
 	if len(marker) != 4 {
-		return m, 0, fmt.Errorf("invalid marker definition")
+		return Marker{}, 0, parseError(ErrInvalidLength, "4 elements", describeLength(marker))
 	}
 
 	// get marker type
 	typ, ok := toInt(marker[0])
 	if !ok {
-		return m, 0, fmt.Errorf("invalid marker type")
+		return Marker{}, 0, parseError(ErrInvalidValue, "number", describe(marker[0]), 0)
 	}
 
 	// check marker type
 	markerType := MarkerType(typ)
 	if markerType != TextMarker && markerType != AtomMarker {
-		return m, 0, fmt.Errorf("invalid marker type")
+		return Marker{}, 0, parseError(ErrInvalidMarkerType, "marker type", strconv.Itoa(typ), 0)
 	}
 
-	// set type
-	m.Type = markerType
+	return parseMarkerValues(markerType, marker, 1, markups, atoms, openMarkups)
+}
+
+func parseMarkerValues(markerType MarkerType, marker List, base int, markups []Markup, atoms []Atom, openMarkups int) (Marker, int, error) {
+	// prepare marker
+	m := Marker{Type: markerType}
 
 	// get opened markups
-	openedMarkups, ok := toList(marker[1])
+	openedMarkups, ok := toList(marker[base])
 	if !ok {
-		return m, 0, fmt.Errorf("invalid marker opened markups")
+		return m, 0, parseError(ErrInvalidValue, "list", describe(marker[base]), base)
 	}
 
 	// allocate open markups
@@ -495,16 +568,16 @@ func parseMarker(marker List, markups []Markup, atoms []Atom, openMarkups int) (
 	}
 
 	// parse opened markups
-	for _, value := range openedMarkups {
+	for i, value := range openedMarkups {
 		// coerce value
 		index, ok := toInt(value)
 		if !ok {
-			return m, 0, fmt.Errorf("invalid marker markup index")
+			return m, 0, parseError(ErrInvalidValue, "number", describe(value), base, i)
 		}
 
 		// check index
-		if index >= len(markups) {
-			return m, 0, fmt.Errorf("invalid marker markup index")
+		if index < 0 || index >= len(markups) {
+			return m, 0, parseError(ErrInvalidIndex, indexRange("markup", len(markups)), strconv.Itoa(index), base, i)
 		}
 
 		// add markup
@@ -515,16 +588,16 @@ func parseMarker(marker List, markups []Markup, atoms []Atom, openMarkups int) (
 	}
 
 	// get closed markups
-	closedMarkups, ok := toInt(marker[2])
+	closedMarkups, ok := toInt(marker[base+1])
 	if !ok {
-		return m, 0, fmt.Errorf("invalid marker closed markup")
+		return m, 0, parseError(ErrInvalidValue, "number", describe(marker[base+1]), base+1)
 	}
 
 	// decrement counter
-	openMarkups -= closedMarkups
-	if openMarkups < 0 {
-		return m, 0, fmt.Errorf("invalid marker open markups count")
+	if closedMarkups < 0 || closedMarkups > openMarkups {
+		return m, 0, parseError(ErrInvalidClosedMarkups, fmt.Sprintf("0 to %d", openMarkups), strconv.Itoa(closedMarkups), base+1)
 	}
+	openMarkups -= closedMarkups
 
 	// set closed markups
 	m.ClosedMarkups = closedMarkups
@@ -532,9 +605,9 @@ func parseMarker(marker List, markups []Markup, atoms []Atom, openMarkups int) (
 	// parse text marker
 	if markerType == TextMarker {
 		// get text
-		text, ok := marker[3].(string)
+		text, ok := marker[base+2].(string)
 		if !ok {
-			return m, 0, fmt.Errorf("invalid marker text")
+			return m, 0, parseError(ErrInvalidValue, "string", describe(marker[base+2]), base+2)
 		}
 
 		// set text
@@ -544,9 +617,14 @@ func parseMarker(marker List, markups []Markup, atoms []Atom, openMarkups int) (
 	// parse atom marker
 	if markerType == AtomMarker {
 		// get index
-		index, ok := toInt(marker[3])
-		if !ok || index >= len(atoms) {
-			return m, 0, fmt.Errorf("invalid marker atom index")
+		index, ok := toInt(marker[base+2])
+		if !ok {
+			return m, 0, parseError(ErrInvalidValue, "number", describe(marker[base+2]), base+2)
+		}
+
+		// check index
+		if index < 0 || index >= len(atoms) {
+			return m, 0, parseError(ErrInvalidIndex, indexRange("atom", len(atoms)), strconv.Itoa(index), base+2)
 		}
 
 		// set atom
@@ -555,3 +633,10 @@ func parseMarker(marker List, markups []Markup, atoms []Atom, openMarkups int) (
 
 	return m, openMarkups, nil
 }
+
+func indexRange(name string, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("no %s index", name)
+	}
+	return fmt.Sprintf("%s index from 0 to %d", name, length-1)
+}
@@ -2,6 +2,7 @@ package mobiledoc
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,6 +45,50 @@ func TestParseNilAndMissing(t *testing.T) {
 	assert.Equal(t, Document{Version: Version}, doc)
 }
 
+func TestParseSectionAttributes(t *testing.T) {
+	doc, err := Parse(Map{
+		"version": Version032,
+		"sections": List{
+			List{MarkupSection, "p", List{}, List{"data-md-text-align", "center"}},
+			List{MarkupSection, "p", List{}, List{}},
+			List{ListSection, "ul", List{}, List{"data-md-text-align", "right"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Document{
+		Version: Version032,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Attributes: Map{"data-md-text-align": "center"}, Markers: []Marker{}},
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{}},
+			{Type: ListSection, Tag: "ul", Attributes: Map{"data-md-text-align": "right"}, Items: [][]Marker{}},
+		},
+	}, doc)
+
+	_, err = Parse(Map{
+		"version": Version,
+		"sections": List{
+			List{MarkupSection, "p", List{}, List{"data-md-text-align", "center"}},
+		},
+	})
+	assert.Error(t, err)
+
+	_, err = Parse(Map{
+		"version": Version032,
+		"sections": List{
+			List{MarkupSection, "p", List{}, 1},
+		},
+	})
+	assert.Error(t, err)
+
+	_, err = Parse(Map{
+		"version": Version032,
+		"sections": List{
+			List{ListSection, "ul", List{}, List{1, "center"}},
+		},
+	})
+	assert.Error(t, err)
+}
+
 func TestParseInvalidDocument(t *testing.T) {
 	_, err := Parse(Map{
 		"version": 1,
@@ -467,6 +512,136 @@ func TestParseInvalidMarker(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestParseErrors(t *testing.T) {
+	_, err := Parse(Map{})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/version",
+		Expected: "string",
+		Actual:   "missing",
+		Kind:     ErrInvalidVersion,
+	}, err)
+	assert.Equal(t, `invalid version at "/version": expected string, got missing`, err.Error())
+
+	_, err = Parse(Map{
+		"version": Version,
+		"markups": List{
+			List{"b"},
+			List{"a", List{"href"}},
+		},
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/markups/1/1",
+		Expected: "even number of elements",
+		Actual:   "1 element",
+		Kind:     ErrInvalidLength,
+	}, err)
+
+	_, err = Parse(Map{
+		"version": Version,
+		"atoms": List{
+			List{"atom", "foo", List{}},
+		},
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/atoms/0/2",
+		Expected: "map",
+		Actual:   "list",
+		Kind:     ErrInvalidValue,
+	}, err)
+
+	_, err = Parse(Map{
+		"version": Version,
+		"markups": List{
+			List{"b"},
+		},
+		"sections": List{
+			List{MarkupSection, "p", List{}},
+			List{ListSection, "ul", List{
+				List{
+					List{TextMarker, List{}, 0, "foo"},
+					List{TextMarker, List{0, 3}, 0, "foo"},
+				},
+			}},
+		},
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/sections/1/2/0/1/1/1",
+		Expected: "markup index from 0 to 0",
+		Actual:   "3",
+		Kind:     ErrInvalidIndex,
+	}, err)
+	assert.True(t, errors.Is(err, ErrInvalidIndex))
+
+	_, err = Parse(Map{
+		"version": Version,
+		"sections": List{
+			List{MarkupSection, "p", List{
+				List{TextMarker, List{}, 1, "foo"},
+			}},
+		},
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/sections/0/2/0/2",
+		Expected: "0 to 0",
+		Actual:   "1",
+		Kind:     ErrInvalidClosedMarkups,
+	}, err)
+
+	_, err = Parse(Map{
+		"version": Version,
+		"sections": List{
+			List{CardSection, -1},
+		},
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/sections/0/1",
+		Expected: "no card index",
+		Actual:   "-1",
+		Kind:     ErrInvalidIndex,
+	}, err)
+
+	_, err = Parse(Map{
+		"version": Version,
+		"sections": List{
+			List{7},
+		},
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/sections/0/0",
+		Expected: "section type",
+		Actual:   "7",
+		Kind:     ErrInvalidSectionType,
+	}, err)
+
+	_, err = ParseAny(Map{
+		"version": Version020,
+		"sections": List{
+			List{},
+			List{
+				List{MarkupSection, "p", List{
+					List{List{}, 0, true},
+				}},
+			},
+		},
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/sections/1/0/2/0/2",
+		Expected: "string",
+		Actual:   "boolean",
+		Kind:     ErrInvalidValue,
+	}, err)
+
+	_, err = ParseAny(Map{
+		"version": "0.1.0",
+	})
+	assert.Equal(t, &ParseError{
+		Pointer:  "/version",
+		Expected: "supported version",
+		Actual:   `"0.1.0"`,
+		Kind:     ErrInvalidVersion,
+	}, err)
+}
+
 func BenchmarkParse(b *testing.B) {
 	in := sampleMap()
 	b.ReportAllocs()
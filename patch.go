@@ -0,0 +1,202 @@
+package mobiledoc
+
+// Patch describes the changes between two revisions of a document as a list
+// of section replacements.
+type Patch struct {
+	// The versions of the old and new document.
+	OldVersion string `json:"old_version" bson:"old_version"`
+	NewVersion string `json:"new_version" bson:"new_version"`
+
+	// The operations to be applied in order.
+	Operations []Operation `json:"operations" bson:"operations"`
+}
+
+// Operation replaces the sections of the old document at the specified index
+// with the sections of the new document. The index refers to the document
+// after all previous operations have been applied.
+type Operation struct {
+	// The index of the first replaced section.
+	Index int `json:"index" bson:"index"`
+
+	// The replaced and replacing sections with their definitions.
+	Old *Document `json:"old,omitempty" bson:"old,omitempty"`
+	New *Document `json:"new,omitempty" bson:"new,omitempty"`
+}
+
+// NewPatch will create a patch that transforms the old document into the new
+// document. Sections are aligned by content and only changed sections are
+// included in the patch.
+func NewPatch(old, new Document) Patch {
+	// compute section keys
+	oldKeys := sectionKeys(old.Sections)
+	newKeys := sectionKeys(new.Sections)
+
+	// compute matches
+	matches := lcs(len(oldKeys), len(newKeys), func(i, j int) bool {
+		return oldKeys[i] == newKeys[j]
+	})
+
+	// prepare patch
+	patch := Patch{
+		OldVersion: old.Version,
+		NewVersion: new.Version,
+	}
+
+	// add operations for gaps between matches
+	i, j := 0, 0
+	for _, match := range append(matches, [2]int{len(oldKeys), len(newKeys)}) {
+		if i < match[0] || j < match[1] {
+			op := Operation{Index: j}
+			if i < match[0] {
+				doc := extractSections(old.Version, old.Sections[i:match[0]])
+				op.Old = &doc
+			}
+			if j < match[1] {
+				doc := extractSections(new.Version, new.Sections[j:match[1]])
+				op.New = &doc
+			}
+			patch.Operations = append(patch.Operations, op)
+		}
+		i, j = match[0]+1, match[1]+1
+	}
+
+	return patch
+}
+
+// Apply will apply the patch to the document and return the new document. An
+// ErrInvalidPatch error is returned if the document does not match the old
+// document the patch was created from.
+func Apply(doc Document, patch Patch) (Document, error) {
+	// check version
+	if patch.OldVersion != "" && doc.Version != patch.OldVersion {
+		return Document{}, ErrInvalidPatch
+	}
+
+	// copy sections
+	sections := append([]Section{}, doc.Sections...)
+
+	// apply operations
+	for _, op := range patch.Operations {
+		// get sections
+		var oldSections, newSections []Section
+		if op.Old != nil {
+			oldSections = op.Old.Sections
+		}
+		if op.New != nil {
+			newSections = op.New.Sections
+		}
+
+		// check range
+		if op.Index < 0 || op.Index+len(oldSections) > len(sections) {
+			return Document{}, ErrInvalidPatch
+		}
+
+		// check replaced sections
+		for i, section := range oldSections {
+			if sectionKey(sections[op.Index+i]) != sectionKey(section) {
+				return Document{}, ErrInvalidPatch
+			}
+		}
+
+		// replace sections
+		tail := append([]Section{}, sections[op.Index+len(oldSections):]...)
+		sections = append(append(sections[:op.Index], newSections...), tail...)
+	}
+
+	// get version
+	version := doc.Version
+	if patch.NewVersion != "" {
+		version = patch.NewVersion
+	}
+
+	return extractSections(version, sections), nil
+}
+
+// Invert will return a patch that reverts the changes of the provided patch.
+func Invert(patch Patch) Patch {
+	// prepare patch
+	inverted := Patch{
+		OldVersion: patch.NewVersion,
+		NewVersion: patch.OldVersion,
+		Operations: make([]Operation, 0, len(patch.Operations)),
+	}
+
+	// swap and reverse operations
+	for i := len(patch.Operations) - 1; i >= 0; i-- {
+		op := patch.Operations[i]
+		inverted.Operations = append(inverted.Operations, Operation{
+			Index: op.Index,
+			Old:   op.New,
+			New:   op.Old,
+		})
+	}
+
+	return inverted
+}
+
+// extractSections will create a document with copies of the specified sections
+// and the markups, atoms and cards they reference in order of first use.
+func extractSections(version string, sections []Section) Document {
+	// prepare document
+	doc := Document{
+		Version:  version,
+		Markups:  []Markup{},
+		Atoms:    []Atom{},
+		Cards:    []Card{},
+		Sections: make([]Section, 0, len(sections)),
+	}
+
+	// prepare indexes
+	markups := map[*Markup]int{}
+	atoms := map[*Atom]int{}
+	cards := map[*Card]int{}
+
+	// collect definitions
+	collect := func(markers []Marker) {
+		for _, marker := range markers {
+			for _, markup := range marker.OpenMarkups {
+				if _, ok := markups[markup]; !ok && markup != nil {
+					markups[markup] = len(doc.Markups)
+					doc.Markups = append(doc.Markups, cloneMarkup(*markup))
+				}
+			}
+			if _, ok := atoms[marker.Atom]; !ok && marker.Atom != nil {
+				atoms[marker.Atom] = len(doc.Atoms)
+				doc.Atoms = append(doc.Atoms, cloneAtom(*marker.Atom))
+			}
+		}
+	}
+	for _, section := range sections {
+		collect(section.Markers)
+		for _, item := range section.Items {
+			collect(item)
+		}
+		if _, ok := cards[section.Card]; !ok && section.Card != nil {
+			cards[section.Card] = len(doc.Cards)
+			doc.Cards = append(doc.Cards, cloneCard(*section.Card))
+		}
+	}
+
+	// prepare cloner with collected definitions
+	c := cloner{
+		markups: map[*Markup]*Markup{},
+		atoms:   map[*Atom]*Atom{},
+		cards:   map[*Card]*Card{},
+	}
+	for markup, index := range markups {
+		c.markups[markup] = &doc.Markups[index]
+	}
+	for atom, index := range atoms {
+		c.atoms[atom] = &doc.Atoms[index]
+	}
+	for card, index := range cards {
+		c.cards[card] = &doc.Cards[index]
+	}
+
+	// copy sections
+	for _, section := range sections {
+		doc.Sections = append(doc.Sections, c.section(section))
+	}
+
+	return doc
+}
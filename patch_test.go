@@ -0,0 +1,79 @@
+package mobiledoc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPatch(t *testing.T) {
+	old := sampleDoc()
+
+	new := sampleDoc()
+	new.Sections[1].Markers[1].OpenMarkups = []*Markup{&new.Markups[1]}
+	new.Sections[4].Items[1][1].Text = "<baz>"
+	new.Sections = append(new.Sections[:2:2], new.Sections[3:]...)
+	new.Sections = append(new.Sections, Section{Type: ImageSection, Source: "https://example.com/bar.png"})
+
+	patch := NewPatch(old, new)
+	require.Len(t, patch.Operations, 3)
+	assert.Equal(t, 1, patch.Operations[0].Index)
+	assert.Len(t, patch.Operations[0].Old.Sections, 2)
+	assert.Len(t, patch.Operations[0].New.Sections, 1)
+	assert.Equal(t, 3, patch.Operations[1].Index)
+	assert.Equal(t, 6, patch.Operations[2].Index)
+	assert.Nil(t, patch.Operations[2].Old)
+
+	res, err := Apply(old, patch)
+	assert.NoError(t, err)
+	assert.True(t, Equal(new, res))
+	assert.NoError(t, NewFormatValidator().Validate(res))
+
+	res, err = Apply(new, Invert(patch))
+	assert.NoError(t, err)
+	assert.True(t, Equal(old, res))
+
+	_, err = Apply(new, patch)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	patch = NewPatch(old, old)
+	assert.Empty(t, patch.Operations)
+
+	res, err = Apply(old, patch)
+	assert.NoError(t, err)
+	assert.True(t, Equal(old, res))
+}
+
+func TestPatchCoding(t *testing.T) {
+	old := sampleDoc()
+
+	new := sampleDoc()
+	new.Cards[1].Payload = Map{"foo": float64(25)}
+	new.Sections = append(new.Sections, Section{Type: ImageSection, Source: "https://example.com/bar.png"})
+	patch := NewPatch(old, new)
+
+	buf, err := json.Marshal(patch)
+	assert.NoError(t, err)
+
+	var p1 Patch
+	err = json.Unmarshal(buf, &p1)
+	assert.NoError(t, err)
+
+	res, err := Apply(old, p1)
+	assert.NoError(t, err)
+	assert.True(t, Equal(new, res))
+
+	buf, err = bson.Marshal(patch)
+	assert.NoError(t, err)
+
+	var p2 Patch
+	err = bson.Unmarshal(buf, &p2)
+	assert.NoError(t, err)
+
+	res, err = Apply(new, Invert(p2))
+	assert.NoError(t, err)
+	assert.True(t, Equal(old, res))
+}
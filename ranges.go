@@ -0,0 +1,215 @@
+package mobiledoc
+
+import "sort"
+
+// AtomPlaceholder is the character used to represent atoms in the text of
+// ranges.
+const AtomPlaceholder = '\uFFFC'
+
+// Ranges represents a list of markers as plain text with markup ranges and atom
+// positions. All offsets are measured in runes.
+type Ranges struct {
+	// The text of all markers with atoms represented by AtomPlaceholder.
+	Text string
+
+	// The markup ranges ordered by start and length.
+	Markups []MarkupRange
+
+	// The atom positions in ascending order.
+	Atoms []AtomPosition
+}
+
+// MarkupRange is a markup applied to the text from Start to End (exclusive).
+type MarkupRange struct {
+	Start  int
+	End    int
+	Markup *Markup
+}
+
+// AtomPosition is an atom placed at the specified offset.
+type AtomPosition struct {
+	Offset int
+	Atom   *Atom
+}
+
+// ToRanges will convert the markers of a markup section or list item to
+// ranges. Adjacent markers with the same markup yield a single range and empty
+// text markers are ignored.
+func ToRanges(markers []Marker) Ranges {
+	// get active markups
+	active := activeMarkups(markers)
+
+	// prepare ranges
+	var ranges Ranges
+	var text []rune
+	var current map[*Markup]int
+
+	for i, marker := range markers {
+		// get content
+		var content []rune
+		if marker.Type == AtomMarker {
+			content = []rune{AtomPlaceholder}
+		} else {
+			content = []rune(marker.Text)
+		}
+
+		// skip empty markers
+		if len(content) == 0 {
+			continue
+		}
+
+		// get offsets
+		start := len(text)
+		end := start + len(content)
+
+		// add atom
+		if marker.Type == AtomMarker {
+			ranges.Atoms = append(ranges.Atoms, AtomPosition{
+				Offset: start,
+				Atom:   marker.Atom,
+			})
+		}
+
+		// extend or add markup ranges
+		next := make(map[*Markup]int, len(active[i]))
+		for _, markup := range active[i] {
+			if index, ok := current[markup]; ok {
+				ranges.Markups[index].End = end
+				next[markup] = index
+				continue
+			}
+			ranges.Markups = append(ranges.Markups, MarkupRange{
+				Start:  start,
+				End:    end,
+				Markup: markup,
+			})
+			next[markup] = len(ranges.Markups) - 1
+		}
+
+		// add text
+		text = append(text, content...)
+		current = next
+	}
+
+	// set text
+	ranges.Text = string(text)
+
+	// sort markup ranges
+	sortMarkupRanges(ranges.Markups)
+
+	return ranges
+}
+
+// FromRanges will convert the ranges to a list of correctly nested markers.
+// Overlapping ranges are split into multiple markers as needed. Atom offsets
+// are expected to point at AtomPlaceholder characters. Ranges outside the text
+// are clamped and empty ranges are ignored.
+func FromRanges(ranges Ranges) []Marker {
+	// get text
+	text := []rune(ranges.Text)
+
+	// collect valid markup ranges
+	list := make([]MarkupRange, 0, len(ranges.Markups))
+	for _, r := range ranges.Markups {
+		if r.Start < 0 {
+			r.Start = 0
+		}
+		if r.End > len(text) {
+			r.End = len(text)
+		}
+		if r.Start < r.End && r.Markup != nil {
+			list = append(list, r)
+		}
+	}
+	sortMarkupRanges(list)
+
+	// collect atoms
+	atoms := map[int]*Atom{}
+	for _, atom := range ranges.Atoms {
+		if atom.Offset >= 0 && atom.Offset < len(text) {
+			atoms[atom.Offset] = atom.Atom
+		}
+	}
+
+	// collect boundaries
+	bounds := map[int]bool{0: true, len(text): true}
+	for _, r := range list {
+		bounds[r.Start] = true
+		bounds[r.End] = true
+	}
+	for offset := range atoms {
+		bounds[offset] = true
+		bounds[offset+1] = true
+	}
+	offsets := make([]int, 0, len(bounds))
+	for offset := range bounds {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+
+	// prepare markers
+	markers := make([]Marker, 0, len(offsets))
+	var active [][]*Markup
+
+	// create markers
+	for i := 0; i+1 < len(offsets); i++ {
+		// get segment
+		start, end := offsets[i], offsets[i+1]
+
+		// get active markups
+		var set []*Markup
+		for _, r := range list {
+			if r.Start <= start && r.End >= end && !containsMarkup(set, r.Markup) {
+				set = append(set, r.Markup)
+			}
+		}
+
+		// add atom marker
+		if atom, ok := atoms[start]; ok {
+			markers = append(markers, Marker{Type: AtomMarker, Atom: atom})
+			active = append(active, set)
+			continue
+		}
+
+		// merge with previous text marker
+		if n := len(markers); n > 0 && markers[n-1].Type == TextMarker && sameMarkups(active[n-1], set) {
+			markers[n-1].Text += string(text[start:end])
+			continue
+		}
+
+		// add text marker
+		markers = append(markers, Marker{Type: TextMarker, Text: string(text[start:end])})
+		active = append(active, set)
+	}
+
+	// balance markers
+	balanceMarkers(markers, active)
+
+	return markers
+}
+
+func sortMarkupRanges(list []MarkupRange) {
+	// sort by start and then by length
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Start != list[j].Start {
+			return list[i].Start < list[j].Start
+		}
+		return list[i].End > list[j].End
+	})
+}
+
+func sameMarkups(a, b []*Markup) bool {
+	// check length
+	if len(a) != len(b) {
+		return false
+	}
+
+	// check markups
+	for _, markup := range a {
+		if !containsMarkup(b, markup) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,79 @@
+package mobiledoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToRanges(t *testing.T) {
+	doc := sampleDoc()
+
+	r := ToRanges(doc.Sections[1].Markers)
+	assert.Equal(t, Ranges{
+		Text: "foofoofoofoofoofoo",
+		Markups: []MarkupRange{
+			{Start: 3, End: 6, Markup: &doc.Markups[0]},
+			{Start: 6, End: 18, Markup: &doc.Markups[1]},
+			{Start: 12, End: 15, Markup: &doc.Markups[2]},
+		},
+	}, r)
+
+	r = ToRanges(doc.Sections[2].Markers)
+	assert.Equal(t, Ranges{
+		Text: "\uFFFC\uFFFC\uFFFC",
+		Markups: []MarkupRange{
+			{Start: 1, End: 3, Markup: &doc.Markups[0]},
+		},
+		Atoms: []AtomPosition{
+			{Offset: 0, Atom: &doc.Atoms[0]},
+			{Offset: 1, Atom: &doc.Atoms[1]},
+			{Offset: 2, Atom: &doc.Atoms[0]},
+		},
+	}, r)
+}
+
+func TestFromRanges(t *testing.T) {
+	b := &Markup{Tag: "b"}
+	i := &Markup{Tag: "i"}
+	atom := &Atom{Name: "foo"}
+
+	markers := FromRanges(Ranges{
+		Text: "äb\uFFFCdef",
+		Markups: []MarkupRange{
+			{Start: 0, End: 4, Markup: b},
+			{Start: 2, End: 10, Markup: i},
+			{Start: 3, End: 3, Markup: i},
+		},
+		Atoms: []AtomPosition{
+			{Offset: 2, Atom: atom},
+		},
+	})
+	assert.Equal(t, []Marker{
+		{Type: TextMarker, OpenMarkups: []*Markup{b}, Text: "äb"},
+		{Type: AtomMarker, OpenMarkups: []*Markup{i}, Atom: atom},
+		{Type: TextMarker, ClosedMarkups: 2, Text: "d"},
+		{Type: TextMarker, OpenMarkups: []*Markup{i}, ClosedMarkups: 1, Text: "ef"},
+	}, markers)
+
+	assert.Equal(t, []Marker{}, FromRanges(Ranges{}))
+}
+
+func TestRangesRoundTrip(t *testing.T) {
+	doc := sampleDoc()
+
+	out := doc.Clone()
+	for i, section := range out.Sections {
+		switch section.Type {
+		case MarkupSection:
+			out.Sections[i].Markers = FromRanges(ToRanges(section.Markers))
+		case ListSection:
+			for j, item := range section.Items {
+				out.Sections[i].Items[j] = FromRanges(ToRanges(item))
+			}
+		}
+	}
+
+	assert.True(t, EqualWith(doc, out, EqualOptions{IgnoreSplits: true}))
+	assert.NoError(t, NewFormatValidator().Validate(out))
+}
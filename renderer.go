@@ -0,0 +1,144 @@
+package mobiledoc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Renderer is the common interface implemented by all renderers.
+type Renderer interface {
+	Render(w io.Writer, doc Document) error
+	RenderContext(ctx context.Context, w io.Writer, doc Document) error
+}
+
+// ensure interface
+var (
+	_ Renderer = &HTMLRenderer{}
+	_ Renderer = &TextRenderer{}
+	_ Renderer = &MarkdownRenderer{}
+)
+
+// renderBuffered will call the provided function with a buffered writer and
+// return the written output.
+func renderBuffered(fn func(*bufio.Writer) error) (string, error) {
+	// prepare writer
+	var buf strings.Builder
+	bw := bufio.NewWriter(&buf)
+
+	// call function
+	err := fn(bw)
+	if err != nil {
+		return "", err
+	}
+
+	// flush buffer
+	err = bw.Flush()
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func contextVisitor(ctx context.Context, v Visitor) Visitor {
+	// check context before entering sections
+	enter := v.EnterSection
+	v.EnterSection = func(loc Location, section *Section) error {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		if enter != nil {
+			return enter(loc, section)
+		}
+		return nil
+	}
+
+	return v
+}
+
+// UnknownPolicy defines how atoms and cards without a renderer are handled.
+type UnknownPolicy int
+
+// The available unknown policies.
+const (
+	// UnknownError fails rendering with an error.
+	UnknownError UnknownPolicy = iota
+
+	// UnknownSkip omits the node.
+	UnknownSkip
+
+	// UnknownText renders the text of atoms. Cards are omitted.
+	UnknownText
+
+	// UnknownPlaceholder renders a renderer specific placeholder.
+	UnknownPlaceholder
+
+	// UnknownHandler calls the fallback atom or card renderer.
+	UnknownHandler
+)
+
+// Fallback configures the handling of atoms and cards without a renderer.
+type Fallback struct {
+	// The policies for unknown atoms and cards.
+	UnknownAtoms UnknownPolicy
+	UnknownCards UnknownPolicy
+
+	// The renderers called by the UnknownHandler policy.
+	FallbackAtom func(context.Context, *bufio.Writer, Location, *Atom) error
+	FallbackCard func(context.Context, *bufio.Writer, Location, *Card) error
+
+	// Missing is called with the kind ("atom" or "card") and name of every
+	// unknown atom and card regardless of the policy.
+	Missing func(loc Location, kind, name string)
+}
+
+func (f *Fallback) renderAtom(ctx context.Context, w *bufio.Writer, loc Location, atom *Atom, text, placeholder string) error {
+	// report missing atom
+	if f.Missing != nil {
+		f.Missing(loc, "atom", atom.Name)
+	}
+
+	// apply policy
+	switch f.UnknownAtoms {
+	case UnknownSkip:
+		return nil
+	case UnknownText:
+		_, err := w.WriteString(text)
+		return err
+	case UnknownPlaceholder:
+		_, err := w.WriteString(placeholder)
+		return err
+	case UnknownHandler:
+		if f.FallbackAtom != nil {
+			return f.FallbackAtom(ctx, w, loc, atom)
+		}
+	}
+
+	return fmt.Errorf("missing atom renderer %q", atom.Name)
+}
+
+func (f *Fallback) renderCard(ctx context.Context, w *bufio.Writer, loc Location, card *Card, placeholder string) error {
+	// report missing card
+	if f.Missing != nil {
+		f.Missing(loc, "card", card.Name)
+	}
+
+	// apply policy
+	switch f.UnknownCards {
+	case UnknownSkip, UnknownText:
+		return nil
+	case UnknownPlaceholder:
+		_, err := w.WriteString(placeholder)
+		return err
+	case UnknownHandler:
+		if f.FallbackCard != nil {
+			return f.FallbackCard(ctx, w, loc, card)
+		}
+	}
+
+	return fmt.Errorf("missing card renderer %q", card.Name)
+}
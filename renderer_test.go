@@ -0,0 +1,218 @@
+package mobiledoc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderer(t *testing.T) {
+	doc, err := NewBuilder().Paragraph().Text("foo", Bold).Build()
+	assert.NoError(t, err)
+
+	for _, item := range []struct {
+		renderer Renderer
+		out      string
+	}{
+		{renderer: NewHTMLRenderer(), out: "<p><b>foo</b></p>"},
+		{renderer: NewTextRenderer(), out: "foo"},
+		{renderer: NewMarkdownRenderer(), out: "**foo**"},
+	} {
+		buf := &bytes.Buffer{}
+		err = item.renderer.Render(buf, doc)
+		assert.NoError(t, err)
+		assert.Equal(t, item.out, buf.String())
+	}
+}
+
+type localeKey struct{}
+
+func TestRenderContext(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("foo ").Atom("user", "@joe", Map{}).
+		Card("embed", Map{}).
+		Build()
+	assert.NoError(t, err)
+
+	var locations []Location
+	atom := func(ctx context.Context, w *bufio.Writer, loc Location, atom *Atom) error {
+		locations = append(locations, loc)
+		_, err := w.WriteString(atom.Text + ":" + ctx.Value(localeKey{}).(string))
+		return err
+	}
+	card := func(ctx context.Context, w *bufio.Writer, loc Location, card *Card) error {
+		locations = append(locations, loc)
+		_, err := w.WriteString(card.Name + ":" + ctx.Value(localeKey{}).(string))
+		return err
+	}
+
+	html := NewHTMLRenderer()
+	html.ContextAtoms["user"] = atom
+	html.ContextCards["embed"] = card
+
+	text := NewTextRenderer()
+	text.ContextAtoms["user"] = atom
+	text.ContextCards["embed"] = card
+
+	markdown := NewMarkdownRenderer()
+	markdown.ContextAtoms["user"] = atom
+	markdown.ContextCards["embed"] = card
+
+	ctx := context.WithValue(context.Background(), localeKey{}, "de")
+
+	for _, item := range []struct {
+		renderer Renderer
+		out      string
+	}{
+		{renderer: html, out: "<p>foo @joe:de</p>embed:de"},
+		{renderer: text, out: "foo  @joe:de\nembed:de"},
+		{renderer: markdown, out: "foo @joe:de\n\nembed:de"},
+	} {
+		locations = nil
+
+		buf := &bytes.Buffer{}
+		err = item.renderer.RenderContext(ctx, buf, doc)
+		assert.NoError(t, err)
+		assert.Equal(t, item.out, buf.String())
+		assert.Equal(t, []Location{
+			{Section: 0, Item: -1, Marker: 1},
+			{Section: 1, Item: -1, Marker: -1},
+		}, locations)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err = item.renderer.RenderContext(cancelled, &bytes.Buffer{}, doc)
+		assert.Equal(t, context.Canceled, err)
+	}
+}
+
+func TestRendererFallback(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("foo").Atom("user", "<joe>", Map{}).
+		Card("embed", Map{}).
+		Build()
+	assert.NoError(t, err)
+
+	atom := func(ctx context.Context, w *bufio.Writer, loc Location, atom *Atom) error {
+		_, err := w.WriteString("(atom)")
+		return err
+	}
+	card := func(ctx context.Context, w *bufio.Writer, loc Location, card *Card) error {
+		_, err := w.WriteString("(card)")
+		return err
+	}
+
+	table := []struct {
+		policy   UnknownPolicy
+		html     string
+		text     string
+		markdown string
+	}{
+		{
+			policy:   UnknownSkip,
+			html:     "<p>foo</p>",
+			text:     "foo",
+			markdown: "foo",
+		},
+		{
+			policy:   UnknownText,
+			html:     "<p>foo&lt;joe&gt;</p>",
+			text:     "foo <joe>",
+			markdown: "foo\\<joe\\>",
+		},
+		{
+			policy:   UnknownPlaceholder,
+			html:     `<p>foo<span data-atom="user"></span></p><div data-card="embed"></div>`,
+			text:     "foo [user]\n[embed]",
+			markdown: "foo\\[user\\]\n\n\\[embed\\]",
+		},
+		{
+			policy:   UnknownHandler,
+			html:     "<p>foo(atom)</p>(card)",
+			text:     "foo (atom)\n(card)",
+			markdown: "foo(atom)\n\n(card)",
+		},
+	}
+
+	for _, item := range table {
+		var missing []string
+		fallback := Fallback{
+			UnknownAtoms: item.policy,
+			UnknownCards: item.policy,
+			FallbackAtom: atom,
+			FallbackCard: card,
+			Missing: func(loc Location, kind, name string) {
+				missing = append(missing, kind+":"+name)
+			},
+		}
+
+		html := NewHTMLRenderer()
+		html.Fallback = fallback
+
+		text := NewTextRenderer()
+		text.Fallback = fallback
+
+		markdown := NewMarkdownRenderer()
+		markdown.Fallback = fallback
+
+		for renderer, out := range map[Renderer]string{
+			html:     item.html,
+			text:     item.text,
+			markdown: item.markdown,
+		} {
+			missing = nil
+
+			buf := &bytes.Buffer{}
+			err = renderer.Render(buf, doc)
+			assert.NoError(t, err)
+			assert.Equal(t, out, buf.String())
+			assert.Equal(t, []string{"atom:user", "card:embed"}, missing)
+		}
+	}
+
+	err = NewHTMLRenderer().Render(&bytes.Buffer{}, doc)
+	assert.EqualError(t, err, `missing atom renderer "user"`)
+
+	r := NewTextRenderer()
+	r.UnknownAtoms = UnknownText
+	err = r.Render(&bytes.Buffer{}, doc)
+	assert.EqualError(t, err, `missing card renderer "embed"`)
+
+	r.UnknownCards = UnknownHandler
+	err = r.Render(&bytes.Buffer{}, doc)
+	assert.EqualError(t, err, `missing card renderer "embed"`)
+}
+
+func TestRendererFallbackSkipSeparators(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("foo").Atom("user", "<joe>", Map{}).Text("bar").
+		Card("embed", Map{}).
+		Paragraph().Text("baz").
+		Build()
+	assert.NoError(t, err)
+
+	fallback := Fallback{
+		UnknownAtoms: UnknownSkip,
+		UnknownCards: UnknownSkip,
+	}
+
+	text := NewTextRenderer()
+	text.Fallback = fallback
+
+	markdown := NewMarkdownRenderer()
+	markdown.Fallback = fallback
+
+	for renderer, out := range map[Renderer]string{
+		text:     "foo bar\nbaz",
+		markdown: "foobar\n\nbaz",
+	} {
+		buf := &bytes.Buffer{}
+		err = renderer.Render(buf, doc)
+		assert.NoError(t, err)
+		assert.Equal(t, out, buf.String())
+	}
+}
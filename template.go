@@ -0,0 +1,163 @@
+package mobiledoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+)
+
+// RenderHTML will validate and render the document using a default HTML
+// renderer and return the result as safe HTML. Unknown atoms are rendered as
+// text and unknown cards are skipped.
+func RenderHTML(doc Document) (template.HTML, error) {
+	return defaultHTMLRenderer().RenderHTML(doc)
+}
+
+// RenderHTML will validate the document using the format validator, render it
+// and return the result as safe HTML. All text, tags and attributes written by
+// the renderer itself are escaped, while the output of the atom, card and
+// image renderers and hooks is included as is and must be safe.
+func (r *HTMLRenderer) RenderHTML(doc Document) (template.HTML, error) {
+	// validate document
+	err := formatValidator.Validate(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return r.renderHTML(doc)
+}
+
+func (r *HTMLRenderer) renderHTML(doc Document) (template.HTML, error) {
+	// render document
+	var buf bytes.Buffer
+	err := r.Render(&buf, doc)
+	if err != nil {
+		return "", err
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// TemplateFuncs returns a function map for use with html/template that
+// provides the "mobiledoc", "mobiledocText" and "mobiledocExcerpt" functions.
+// The excerpt is limited to the specified number of characters, which must be
+// positive, and omits cards.
+// The functions accept a Document, a *Document, a raw Map or a JSON string and
+// validate the document using the format validator. Default renderers are used
+// if the provided renderers are nil.
+//
+//	{{ mobiledoc .Body }}
+//	{{ mobiledocText .Body }}
+//	{{ mobiledocExcerpt 200 .Body }}
+func TemplateFuncs(html *HTMLRenderer, text *TextRenderer) template.FuncMap {
+	// ensure renderers
+	if html == nil {
+		html = defaultHTMLRenderer()
+	}
+	if text == nil {
+		text = defaultTextRenderer()
+	}
+
+	return template.FuncMap{
+		"mobiledoc": func(value interface{}) (template.HTML, error) {
+			// get document
+			doc, err := templateDocument(value)
+			if err != nil {
+				return "", err
+			}
+
+			return html.renderHTML(doc)
+		},
+		"mobiledocText": func(value interface{}) (string, error) {
+			// get document
+			doc, err := templateDocument(value)
+			if err != nil {
+				return "", err
+			}
+
+			return renderText(text, doc)
+		},
+		"mobiledocExcerpt": func(length int, value interface{}) (template.HTML, error) {
+			// check length
+			if length <= 0 {
+				return "", fmt.Errorf("invalid excerpt length %d", length)
+			}
+
+			// get document
+			doc, err := templateDocument(value)
+			if err != nil {
+				return "", err
+			}
+
+			// get excerpt
+			doc = Excerpt(doc, ExcerptOptions{
+				MaxChars:  length,
+				SkipCards: true,
+				Ellipsis:  "…",
+			})
+
+			return html.renderHTML(doc)
+		},
+	}
+}
+
+func defaultHTMLRenderer() *HTMLRenderer {
+	r := NewHTMLRenderer()
+	r.UnknownAtoms = UnknownText
+	r.UnknownCards = UnknownSkip
+	return r
+}
+
+func defaultTextRenderer() *TextRenderer {
+	r := NewTextRenderer()
+	r.UnknownAtoms = UnknownText
+	r.UnknownCards = UnknownSkip
+	return r
+}
+
+func templateDocument(value interface{}) (Document, error) {
+	// convert value
+	var doc Document
+	switch value := value.(type) {
+	case Document:
+		doc = value
+	case *Document:
+		if value == nil {
+			return Document{}, nil
+		}
+		doc = *value
+	case Map:
+		parsed, err := ParseAny(value)
+		if err != nil {
+			return Document{}, err
+		}
+		doc = Upgrade(parsed)
+	case string:
+		err := json.Unmarshal([]byte(value), &doc)
+		if err != nil {
+			return Document{}, err
+		}
+	default:
+		return Document{}, fmt.Errorf("unsupported document value %T", value)
+	}
+
+	// validate document
+	err := formatValidator.Validate(doc)
+	if err != nil {
+		return Document{}, err
+	}
+
+	return doc, nil
+}
+
+func renderText(r *TextRenderer, doc Document) (string, error) {
+	// render document
+	var buf bytes.Buffer
+	err := r.Render(&buf, doc)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
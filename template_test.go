@@ -0,0 +1,134 @@
+package mobiledoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTML(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("<foo> ").Atom("user", "@joe", Map{}).
+		Card("embed", Map{}).
+		Build()
+	require.NoError(t, err)
+
+	out, err := RenderHTML(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML("<p>&lt;foo&gt; @joe</p>"), out)
+
+	_, err = NewHTMLRenderer().RenderHTML(doc)
+	assert.Error(t, err)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("Hello").Text("<world>", Bold).
+		Paragraph().Text("The quick brown fox jumps over the lazy dog.").
+		Build()
+	require.NoError(t, err)
+
+	tmpl, err := template.New("").Funcs(TemplateFuncs(nil, nil)).Parse(
+		`<div>{{ mobiledoc . }}</div><div title="{{ mobiledocText . }}">{{ mobiledocExcerpt 20 . }}</div>`,
+	)
+	require.NoError(t, err)
+
+	raw, err := Compile(doc)
+	require.NoError(t, err)
+
+	str, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	for _, value := range []interface{}{doc, &doc, raw, string(str)} {
+		var buf bytes.Buffer
+		err = tmpl.Execute(&buf, value)
+		assert.NoError(t, err)
+		assert.Equal(t, `<div><p>Hello<b>&lt;world&gt;</b></p><p>The quick brown fox jumps over the lazy dog.</p></div>`+
+			`<div title="Hello &lt;world&gt;
+The quick brown fox jumps over the lazy dog."><p>Hello<b>&lt;world&gt;</b></p><p>The…</p></div>`, buf.String())
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, (*Document)(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, `<div></div><div title=""></div>`, buf.String())
+
+	err = tmpl.Execute(&bytes.Buffer{}, 42)
+	assert.Error(t, err)
+
+	for _, length := range []string{"0", "-1"} {
+		tmpl, err := template.New("").Funcs(TemplateFuncs(nil, nil)).Parse(`{{ mobiledocExcerpt ` + length + ` . }}`)
+		require.NoError(t, err)
+
+		err = tmpl.Execute(&bytes.Buffer{}, doc)
+		assert.Error(t, err)
+	}
+}
+
+func TestTemplateFuncsUnsafe(t *testing.T) {
+	raw := Map{
+		"version": Version031,
+		"markups": List{
+			List{"a", List{"href", "https://x", "onmouseover", "alert(1)"}},
+			List{"script"},
+		},
+		"atoms": List{},
+		"cards": List{},
+		"sections": List{
+			List{1, "p", List{
+				List{0, List{0}, 1, "foo"},
+				List{0, List{1}, 1, "alert(1)"},
+			}},
+		},
+	}
+
+	doc := Document{
+		Version: Version031,
+		Markups: []Markup{
+			{Tag: "a", Attributes: Map{"href": "https://x", "onmouseover": "alert(1)"}},
+			{Tag: "script"},
+		},
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{
+				{Type: TextMarker, Text: "foo", ClosedMarkups: 1},
+				{Type: TextMarker, Text: "alert(1)", ClosedMarkups: 1},
+			}},
+		},
+	}
+	doc.Sections[0].Markers[0].OpenMarkups = []*Markup{&doc.Markups[0]}
+	doc.Sections[0].Markers[1].OpenMarkups = []*Markup{&doc.Markups[1]}
+
+	str, err := json.Marshal(raw)
+	require.NoError(t, err)
+
+	tmpl, err := template.New("").Funcs(TemplateFuncs(nil, nil)).Parse(
+		`{{ mobiledoc . }}{{ mobiledocText . }}{{ mobiledocExcerpt 20 . }}`,
+	)
+	require.NoError(t, err)
+
+	for _, value := range []interface{}{raw, doc, &doc, string(str)} {
+		var buf bytes.Buffer
+		err = tmpl.Execute(&buf, value)
+		assert.Error(t, err)
+		assert.NotContains(t, buf.String(), "alert")
+	}
+
+	out, err := RenderHTML(doc)
+	assert.Error(t, err)
+	assert.Empty(t, out)
+
+	out, err = NewHTMLRenderer().RenderHTML(doc)
+	assert.Error(t, err)
+	assert.Empty(t, out)
+
+	doc.Markups = doc.Markups[:1]
+	doc.Sections[0].Markers = doc.Sections[0].Markers[:1]
+
+	out, err = RenderHTML(doc)
+	assert.Error(t, err)
+	assert.Empty(t, out)
+}
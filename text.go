@@ -2,6 +2,7 @@ package mobiledoc
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 )
@@ -10,39 +11,54 @@ import (
 type TextRenderer struct {
 	Atoms map[string]func(*bufio.Writer, string, Map) error
 	Cards map[string]func(*bufio.Writer, Map) error
+
+	// ContextAtoms and ContextCards are context-aware atom and card renderers
+	// that also receive the location of the node. They take precedence over
+	// Atoms and Cards.
+	ContextAtoms map[string]func(context.Context, *bufio.Writer, Location, *Atom) error
+	ContextCards map[string]func(context.Context, *bufio.Writer, Location, *Card) error
+
+	// Fallback configures the handling of unknown atoms and cards. Placeholders
+	// are the atom or card name in brackets.
+	Fallback
+
+	// Image may render image sections. Defaults to the source in brackets.
+	Image func(w *bufio.Writer, section *Section) error
+
+	// Text may render text markers. Defaults to the plain text.
+	Text func(w *bufio.Writer, text string) error
 }
 
 // NewTextRenderer creates a new TextRenderer.
 func NewTextRenderer() *TextRenderer {
 	return &TextRenderer{
-		Atoms: make(map[string]func(*bufio.Writer, string, Map) error),
-		Cards: make(map[string]func(*bufio.Writer, Map) error),
+		Atoms:        make(map[string]func(*bufio.Writer, string, Map) error),
+		Cards:        make(map[string]func(*bufio.Writer, Map) error),
+		ContextAtoms: make(map[string]func(context.Context, *bufio.Writer, Location, *Atom) error),
+		ContextCards: make(map[string]func(context.Context, *bufio.Writer, Location, *Card) error),
 	}
 }
 
 // Render will render the document to the provided writer.
 func (r *TextRenderer) Render(w io.Writer, doc Document) error {
+	return r.RenderContext(context.Background(), w, doc)
+}
+
+// RenderContext will render the document to the provided writer. The context
+// is passed to the context-aware atom and card renderers and rendering is
+// aborted if the context is cancelled.
+func (r *TextRenderer) RenderContext(ctx context.Context, w io.Writer, doc Document) error {
 	// wrap writer
 	bw := bufio.NewWriter(w)
 
-	// render sections
-	for i, section := range doc.Sections {
-		err := r.renderSection(bw, section)
-		if err != nil {
-			return err
-		}
-
-		// write newline
-		if i < len(doc.Sections)-1 {
-			_, err = bw.WriteString("\n")
-			if err != nil {
-				return err
-			}
-		}
+	// walk document
+	err := Walk(doc, contextVisitor(ctx, r.visitor(ctx, bw, doc)))
+	if err != nil {
+		return err
 	}
 
 	// flush buffer
-	err := bw.Flush()
+	err = bw.Flush()
 	if err != nil {
 		return err
 	}
@@ -50,84 +66,147 @@ func (r *TextRenderer) Render(w io.Writer, doc Document) error {
 	return nil
 }
 
-func (r *TextRenderer) renderSection(w *bufio.Writer, section Section) error {
-	// select sub renderer based on type
-	switch section.Type {
-	case MarkupSection:
-		return r.renderMarkupSection(w, section)
-	case ImageSection:
-		return r.renderImageSection(w, section)
-	case ListSection:
-		return r.renderListSection(w, section)
-	case CardSection:
-		return r.renderCardSection(w, section)
-	}
+func (r *TextRenderer) visitor(ctx context.Context, w *bufio.Writer, doc Document) Visitor {
+	// prepare flags, separators are only written between nodes that produced
+	// output
+	section, marker := false, false
 
-	return nil
-}
-
-func (r *TextRenderer) renderMarkupSection(w *bufio.Writer, section Section) error {
-	// render markers
-	err := r.renderMarkers(w, section.Markers)
-	if err != nil {
-		return err
+	// write newline between sections
+	separateSection := func() error {
+		if section {
+			_, err := w.WriteString("\n")
+			if err != nil {
+				return err
+			}
+		}
+		section = true
+		return nil
 	}
 
-	return nil
-}
-
-func (r *TextRenderer) renderImageSection(w *bufio.Writer, section Section) error {
-	// write source
-	_, err := w.WriteString(fmt.Sprintf("[%s]", section.Source))
-	if err != nil {
-		return err
+	// write space between markers
+	separateMarker := func() error {
+		if marker {
+			_, err := w.WriteString(" ")
+			if err != nil {
+				return err
+			}
+		}
+		marker = true
+		return nil
 	}
 
-	return nil
-}
+	return Visitor{
+		EnterSection: func(_ Location, s *Section) error {
+			// reset marker flag
+			marker = false
+
+			// cards are separated when rendered
+			if s.Type == CardSection {
+				return nil
+			}
 
-func (r *TextRenderer) renderListSection(w *bufio.Writer, section Section) error {
-	// write all items
-	for i, item := range section.Items {
-		// write dash
-		if section.Tag == "ol" {
-			_, err := w.WriteString(fmt.Sprintf("%d. ", i+1))
+			// write newline
+			err := separateSection()
 			if err != nil {
 				return err
 			}
-		} else {
+
+			// write image source
+			if s.Type == ImageSection {
+				if r.Image != nil {
+					return r.Image(w, s)
+				}
+				_, err := w.WriteString(fmt.Sprintf("[%s]", s.Source))
+				return err
+			}
+
+			return nil
+		},
+		EnterItem: func(loc Location, _ []Marker) error {
+			// reset marker flag
+			marker = false
+
+			// write dash or number
+			if doc.Sections[loc.Section].Tag == "ol" {
+				_, err := w.WriteString(fmt.Sprintf("%d. ", loc.Item+1))
+				return err
+			}
 			_, err := w.WriteString("- ")
+			return err
+		},
+		LeaveItem: func(loc Location, _ []Marker) error {
+			// write newline
+			if loc.Item < len(doc.Sections[loc.Section].Items)-1 {
+				_, err := w.WriteString("\n")
+				return err
+			}
+			return nil
+		},
+		Text: func(loc Location, text string) error {
+			// write space
+			err := separateMarker()
 			if err != nil {
 				return err
 			}
-		}
 
-		// render markers
-		err := r.renderMarkers(w, item)
-		if err != nil {
+			// write text
+			if r.Text != nil {
+				return r.Text(w, text)
+			}
+			_, err = w.WriteString(text)
 			return err
-		}
+		},
+		Atom: func(loc Location, atom *Atom) error {
+			// render atom
+			out, err := renderBuffered(func(bw *bufio.Writer) error {
+				return r.renderAtom(ctx, bw, loc, atom)
+			})
+			if err != nil || out == "" {
+				return err
+			}
 
-		if i < len(section.Items)-1 {
-			_, err := w.WriteString("\n")
+			// write space and atom
+			err = separateMarker()
 			if err != nil {
 				return err
 			}
-		}
-	}
+			_, err = w.WriteString(out)
+			return err
+		},
+		Card: func(loc Location, card *Card) error {
+			// render card
+			out, err := renderBuffered(func(bw *bufio.Writer) error {
+				return r.renderCard(ctx, bw, loc, card)
+			})
+			if err != nil || out == "" {
+				return err
+			}
 
-	return nil
+			// write newline and card
+			err = separateSection()
+			if err != nil {
+				return err
+			}
+			_, err = w.WriteString(out)
+			return err
+		},
+	}
 }
 
-func (r *TextRenderer) renderCardSection(w *bufio.Writer, section Section) error {
+func (r *TextRenderer) renderCard(ctx context.Context, w *bufio.Writer, loc Location, card *Card) error {
+	// get context card renderer
+	if renderer, ok := r.ContextCards[card.Name]; ok {
+		return renderer(ctx, w, loc, card)
+	}
+
 	// get card renderer
-	renderer, ok := r.Cards[section.Card.Name]
+	renderer, ok := r.Cards[card.Name]
 	if !ok {
-		return fmt.Errorf("missing card renderer")
+		return r.Fallback.renderCard(ctx, w, loc, card, fmt.Sprintf("[%s]", card.Name))
 	}
 
 	// call renderer
-	err := renderer(w, section.Card.Payload)
+	err := renderer(w, card.Payload)
 	if err != nil {
 		return err
 	}
@@ -135,38 +214,22 @@ func (r *TextRenderer) renderCardSection(w *bufio.Writer, section Section) error
 	return nil
 }
 
-func (r *TextRenderer) renderMarkers(w *bufio.Writer, markers []Marker) error {
-	// write all markers
-	for i, marker := range markers {
-		// write marker
-		switch marker.Type {
-		case TextMarker:
-			// write text
-			_, err := w.WriteString(marker.Text)
-			if err != nil {
-				return err
-			}
-		case AtomMarker:
-			// get renderer
-			renderer, ok := r.Atoms[marker.Atom.Name]
-			if !ok {
-				return fmt.Errorf("missing atom renderer")
-			}
+func (r *TextRenderer) renderAtom(ctx context.Context, w *bufio.Writer, loc Location, atom *Atom) error {
+	// get context atom renderer
+	if renderer, ok := r.ContextAtoms[atom.Name]; ok {
+		return renderer(ctx, w, loc, atom)
+	}
 
-			// call renderer
-			err := renderer(w, marker.Atom.Text, marker.Atom.Payload)
-			if err != nil {
-				return err
-			}
-		}
+	// get renderer
+	renderer, ok := r.Atoms[atom.Name]
+	if !ok {
+		return r.Fallback.renderAtom(ctx, w, loc, atom, atom.Text, fmt.Sprintf("[%s]", atom.Name))
+	}
 
-		// write space
-		if i < len(markers)-1 {
-			_, err := w.WriteString(" ")
-			if err != nil {
-				return err
-			}
-		}
+	// call renderer
+	err := renderer(w, atom.Text, atom.Payload)
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -43,3 +43,26 @@ foo foo foo foo foo foo
 	assert.NoError(t, err)
 	assert.Equal(t, out, buf.String())
 }
+
+func TestTextRendererHooks(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("foo").
+		Image("/foo.png").
+		Build()
+	assert.NoError(t, err)
+
+	r := NewTextRenderer()
+	r.Image = func(w *bufio.Writer, section *Section) error {
+		_, err := w.WriteString("(image)")
+		return err
+	}
+	r.Text = func(w *bufio.Writer, text string) error {
+		_, err := w.WriteString("<" + text + ">")
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	err = r.Render(buf, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "<foo>\n(image)", buf.String())
+}
@@ -0,0 +1,234 @@
+package mobiledoc
+
+// Transformer defines the hooks used when transforming a document. All hooks
+// are optional.
+type Transformer struct {
+	// Called once for every referenced markup. The returned markup replaces
+	// all references to the original markup. If nil is returned, the markup is
+	// removed from all markers.
+	Markup func(markup *Markup) (*Markup, error)
+
+	// Called once for every referenced atom. The returned atom replaces all
+	// references to the original atom. If nil is returned, all markers that
+	// reference the atom are removed.
+	Atom func(atom *Atom) (*Atom, error)
+
+	// Called once for every referenced card. The returned card replaces all
+	// references to the original card. If nil is returned, all sections that
+	// reference the card are removed.
+	Card func(card *Card) (*Card, error)
+
+	// Called for every marker after its markups and atom have been replaced.
+	// The open markups of the provided marker list all markups that are active
+	// for the marker and the closed markups are zero. The returned markers are
+	// interpreted the same way and the open and closed markups are recomputed
+	// afterwards.
+	Marker func(loc Location, marker Marker) ([]Marker, error)
+
+	// Called for every section after its content has been transformed. The
+	// returned sections replace the section.
+	Section func(loc Location, section Section) ([]Section, error)
+}
+
+// Transform will return a transformed copy of the document. The markups,
+// atoms and cards referenced by the resulting sections are collected in the
+// order of first use and the references are rewired accordingly. Definitions
+// that are no longer referenced are removed.
+func Transform(doc Document, t Transformer) (Document, error) {
+	// prepare transformer
+	tr := transformer{
+		Transformer: t,
+		markups:     map[*Markup]*Markup{},
+		atoms:       map[*Atom]*Atom{},
+		cards:       map[*Card]*Card{},
+	}
+
+	// transform sections
+	sections := make([]Section, 0, len(doc.Sections))
+	for i, section := range doc.Sections {
+		list, err := tr.section(i, section)
+		if err != nil {
+			return Document{}, err
+		}
+		sections = append(sections, list...)
+	}
+
+	return extractSections(doc.Version, sections), nil
+}
+
+type transformer struct {
+	Transformer
+	markups map[*Markup]*Markup
+	atoms   map[*Atom]*Atom
+	cards   map[*Card]*Card
+}
+
+func (t *transformer) section(index int, section Section) ([]Section, error) {
+	// get location
+	loc := Location{Section: index, Item: -1, Marker: -1}
+
+	// transform content
+	switch section.Type {
+	case MarkupSection:
+		markers, err := t.markerList(loc, section.Markers)
+		if err != nil {
+			return nil, err
+		}
+		section.Markers = markers
+	case ListSection:
+		if section.Items != nil {
+			items := make([][]Marker, 0, len(section.Items))
+			for i, item := range section.Items {
+				markers, err := t.markerList(Location{Section: index, Item: i, Marker: -1}, item)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, markers)
+			}
+			section.Items = items
+		}
+	case CardSection:
+		if section.Card != nil {
+			card, err := t.card(section.Card)
+			if err != nil {
+				return nil, err
+			} else if card == nil {
+				return nil, nil
+			}
+			section.Card = card
+		}
+	}
+
+	// call hook
+	if t.Section != nil {
+		return t.Section(loc, section)
+	}
+
+	return []Section{section}, nil
+}
+
+func (t *transformer) markerList(loc Location, markers []Marker) ([]Marker, error) {
+	// check nil
+	if markers == nil {
+		return nil, nil
+	}
+
+	// get active markups
+	active := activeMarkups(markers)
+
+	// transform markers
+	list := make([]Marker, 0, len(markers))
+	for i, marker := range markers {
+		// set location
+		loc.Marker = i
+
+		// replace markups
+		var markups []*Markup
+		for _, markup := range active[i] {
+			replacement, err := t.markup(markup)
+			if err != nil {
+				return nil, err
+			} else if replacement != nil && !containsMarkup(markups, replacement) {
+				markups = append(markups, replacement)
+			}
+		}
+		marker.OpenMarkups = markups
+		marker.ClosedMarkups = 0
+
+		// replace atom
+		if marker.Type == AtomMarker && marker.Atom != nil {
+			atom, err := t.atom(marker.Atom)
+			if err != nil {
+				return nil, err
+			} else if atom == nil {
+				continue
+			}
+			marker.Atom = atom
+		}
+
+		// call hook
+		if t.Marker != nil {
+			replacements, err := t.Marker(loc, marker)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, replacements...)
+		} else {
+			list = append(list, marker)
+		}
+	}
+
+	// collect active markups
+	active = make([][]*Markup, len(list))
+	for i, marker := range list {
+		active[i] = marker.OpenMarkups
+	}
+
+	// balance markers
+	balanceMarkers(list, active)
+
+	return list, nil
+}
+
+func (t *transformer) markup(markup *Markup) (*Markup, error) {
+	// check hook
+	if t.Markup == nil {
+		return markup, nil
+	}
+
+	// get cached replacement
+	if replacement, ok := t.markups[markup]; ok {
+		return replacement, nil
+	}
+
+	// call hook
+	replacement, err := t.Markup(markup)
+	if err != nil {
+		return nil, err
+	}
+	t.markups[markup] = replacement
+
+	return replacement, nil
+}
+
+func (t *transformer) atom(atom *Atom) (*Atom, error) {
+	// check hook
+	if t.Atom == nil {
+		return atom, nil
+	}
+
+	// get cached replacement
+	if replacement, ok := t.atoms[atom]; ok {
+		return replacement, nil
+	}
+
+	// call hook
+	replacement, err := t.Atom(atom)
+	if err != nil {
+		return nil, err
+	}
+	t.atoms[atom] = replacement
+
+	return replacement, nil
+}
+
+func (t *transformer) card(card *Card) (*Card, error) {
+	// check hook
+	if t.Card == nil {
+		return card, nil
+	}
+
+	// get cached replacement
+	if replacement, ok := t.cards[card]; ok {
+		return replacement, nil
+	}
+
+	// call hook
+	replacement, err := t.Card(card)
+	if err != nil {
+		return nil, err
+	}
+	t.cards[card] = replacement
+
+	return replacement, nil
+}
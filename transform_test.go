@@ -0,0 +1,178 @@
+package mobiledoc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform(t *testing.T) {
+	doc := sampleDoc()
+
+	res, err := Transform(doc, Transformer{})
+	assert.NoError(t, err)
+	assert.True(t, Equal(doc, res))
+	assert.NotSame(t, &doc.Markups[0], &res.Markups[0])
+	assert.Same(t, &res.Markups[0], res.Sections[1].Markers[1].OpenMarkups[0])
+}
+
+func TestTransformMarkup(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("a").Text("b", Bold).Text("c", Bold, Italic).Text("d", Italic).
+		Build()
+	require.NoError(t, err)
+
+	res, err := Transform(doc, Transformer{
+		Markup: func(markup *Markup) (*Markup, error) {
+			if markup.Tag == "b" {
+				return &Markup{Tag: "strong"}, nil
+			}
+			return markup, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Markup{{Tag: "strong"}, {Tag: "i"}}, res.Markups)
+
+	strong := Markup{Tag: "strong"}
+	expected, err := NewBuilder().
+		Paragraph().Text("a").Text("b", strong).Text("c", strong, Italic).Text("d", Italic).
+		Build()
+	require.NoError(t, err)
+	assert.True(t, Equal(expected, res))
+
+	res, err = Transform(doc, Transformer{
+		Markup: func(markup *Markup) (*Markup, error) {
+			if markup.Tag == "b" {
+				return nil, nil
+			}
+			return markup, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Markup{{Tag: "i"}}, res.Markups)
+
+	expected, err = NewBuilder().
+		Paragraph().Text("ab").Text("cd", Italic).
+		Build()
+	require.NoError(t, err)
+	assert.True(t, EqualWith(expected, res, EqualOptions{IgnoreSplits: true}))
+}
+
+func TestTransformAtomAndCard(t *testing.T) {
+	doc := sampleDoc()
+
+	res, err := Transform(doc, Transformer{
+		Atom: func(atom *Atom) (*Atom, error) {
+			return nil, nil
+		},
+		Card: func(card *Card) (*Card, error) {
+			return &Card{Name: "atom", Payload: card.Payload}, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Atoms)
+	assert.Empty(t, res.Sections[2].Markers)
+	assert.Equal(t, "atom", res.Cards[0].Name)
+	assert.Equal(t, "card1", doc.Cards[0].Name)
+
+	res, err = Transform(doc, Transformer{
+		Card: func(card *Card) (*Card, error) {
+			return nil, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Cards)
+	assert.Len(t, res.Atoms, 2)
+	assert.True(t, Equal(Document{
+		Version:  doc.Version,
+		Sections: doc.Sections[1:6],
+	}, res))
+}
+
+func TestTransformMarker(t *testing.T) {
+	doc, err := NewBuilder().
+		Paragraph().Text("Hello ").Text("bold", Bold).Text(" world").
+		Paragraph().Text("keep me").
+		Build()
+	require.NoError(t, err)
+
+	res, err := Transform(doc, Transformer{
+		Marker: func(loc Location, marker Marker) ([]Marker, error) {
+			if marker.Type != TextMarker || loc.Section != 0 {
+				return []Marker{marker}, nil
+			}
+			var list []Marker
+			for _, word := range strings.SplitAfter(marker.Text, " ") {
+				if word != "" {
+					marker.Text = strings.ToUpper(word)
+					list = append(list, marker)
+				}
+			}
+			return list, nil
+		},
+	})
+	assert.NoError(t, err)
+
+	expected, err := NewBuilder().
+		Paragraph().Text("HELLO ").Text("BOLD", Bold).Text(" ").Text("WORLD").
+		Paragraph().Text("keep me").
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, expected, res)
+}
+
+func TestTransformNilMarkup(t *testing.T) {
+	doc := sampleDoc()
+
+	assert.NotPanics(t, func() {
+		res, err := Transform(doc, Transformer{
+			Marker: func(loc Location, marker Marker) ([]Marker, error) {
+				marker.OpenMarkups = append(marker.OpenMarkups, nil)
+				return []Marker{marker}, nil
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, res.Markups, len(doc.Markups))
+	})
+}
+
+func TestTransformSection(t *testing.T) {
+	doc, err := NewBuilder().
+		Image("/foo.png").
+		Paragraph().Text("foo", Bold).
+		Build()
+	require.NoError(t, err)
+
+	res, err := Transform(doc, Transformer{
+		Section: func(loc Location, section Section) ([]Section, error) {
+			if section.Type == ImageSection {
+				section.Source = "https://cdn.example.com" + section.Source
+				return []Section{section, section}, nil
+			}
+			return nil, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Markups)
+
+	expected, err := NewBuilder().
+		Image("https://cdn.example.com/foo.png").
+		Image("https://cdn.example.com/foo.png").
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, expected, res)
+}
+
+func TestTransformError(t *testing.T) {
+	fail := errors.New("fail")
+
+	_, err := Transform(sampleDoc(), Transformer{
+		Markup: func(markup *Markup) (*Markup, error) {
+			return nil, fail
+		},
+	})
+	assert.Equal(t, fail, err)
+}
@@ -56,12 +56,13 @@ type Card struct {
 
 // Section is a single section.
 type Section struct {
-	Type    SectionType
-	Tag     string
-	Markers []Marker
-	Source  string
-	Items   [][]Marker
-	Card    *Card
+	Type       SectionType
+	Tag        string
+	Attributes Map
+	Markers    []Marker
+	Source     string
+	Items      [][]Marker
+	Card       *Card
 }
 
 // Marker is a single marker.
@@ -4,8 +4,16 @@ import "fmt"
 
 var formatValidator = NewFormatValidator()
 
-// Version specifies the mobiledoc version.
-const Version = "0.3.1"
+// The supported mobiledoc versions.
+const (
+	Version020 = "0.2.0"
+	Version030 = "0.3.0"
+	Version031 = "0.3.1"
+	Version032 = "0.3.2"
+)
+
+// Version specifies the default mobiledoc version.
+const Version = Version031
 
 // LinkValidator validates the href attribute.
 func LinkValidator(attributes Map) bool {
@@ -25,6 +33,28 @@ func LinkValidator(attributes Map) bool {
 	return true
 }
 
+// TextAlignments defines the allowed values of the data-md-text-align section
+// attribute.
+var TextAlignments = []string{"left", "center", "right", "justify"}
+
+// TextAlignValidator validates the data-md-text-align attribute.
+func TextAlignValidator(attributes Map) bool {
+	for key, value := range attributes {
+		switch key {
+		case "data-md-text-align":
+			// get alignment
+			str, ok := value.(string)
+			if !ok || !contains(TextAlignments, str) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 // NoAttributesValidator returns true if the provided attributes are empty.
 func NoAttributesValidator(attributes Map) bool {
 	return len(attributes) == 0
@@ -51,6 +81,22 @@ var DefaultMarkupSections = []string{"aside", "blockquote", "h1", "h2", "h3", "h
 // DefaultListSections defines the default list sections.
 var DefaultListSections = []string{"ul", "ol"}
 
+// DefaultSectionAttributes defines the default section attributes with the
+// section tag as the key and an attributes validator function.
+var DefaultSectionAttributes = map[string]func(Map) bool{
+	"aside":      TextAlignValidator,
+	"blockquote": TextAlignValidator,
+	"h1":         TextAlignValidator,
+	"h2":         TextAlignValidator,
+	"h3":         TextAlignValidator,
+	"h4":         TextAlignValidator,
+	"h5":         TextAlignValidator,
+	"h6":         TextAlignValidator,
+	"p":          TextAlignValidator,
+	"ul":         TextAlignValidator,
+	"ol":         TextAlignValidator,
+}
+
 // DefaultImageSection defines the default image section validator.
 var DefaultImageSection = func(source string) bool {
 	return len(source) > 0
@@ -80,6 +126,11 @@ type Validator struct {
 	// ListSections defines the allowed list sections.
 	ListSections []string
 
+	// SectionAttributes defines the allowed attributes of markup and list
+	// sections with the tag as key and an attributes validator function.
+	// Section attributes are only allowed in 0.3.2 documents.
+	SectionAttributes map[string]func(attributes Map) bool
+
 	// ImageSection defines whether the image section is allowed when a source
 	// validator is set.
 	ImageSection func(source string) bool
@@ -88,9 +139,10 @@ type Validator struct {
 // NewEmptyValidator creates an empty validator.
 func NewEmptyValidator() *Validator {
 	return &Validator{
-		Markups: make(map[string]func(Map) bool),
-		Atoms:   make(map[string]func(string, Map) bool),
-		Cards:   make(map[string]func(Map) bool),
+		Markups:           make(map[string]func(Map) bool),
+		Atoms:             make(map[string]func(string, Map) bool),
+		Cards:             make(map[string]func(Map) bool),
+		SectionAttributes: make(map[string]func(Map) bool),
 	}
 }
 
@@ -98,12 +150,13 @@ func NewEmptyValidator() *Validator {
 // standard excluding atoms and cards.
 func NewDefaultValidator() *Validator {
 	return &Validator{
-		Markups:        DefaultMarkups,
-		Atoms:          make(map[string]func(string, Map) bool),
-		Cards:          make(map[string]func(Map) bool),
-		MarkupSections: DefaultMarkupSections,
-		ListSections:   DefaultListSections,
-		ImageSection:   DefaultImageSection,
+		Markups:           DefaultMarkups,
+		Atoms:             make(map[string]func(string, Map) bool),
+		Cards:             make(map[string]func(Map) bool),
+		MarkupSections:    DefaultMarkupSections,
+		ListSections:      DefaultListSections,
+		SectionAttributes: DefaultSectionAttributes,
+		ImageSection:      DefaultImageSection,
 	}
 }
 
@@ -111,159 +164,314 @@ func NewDefaultValidator() *Validator {
 // standard including atoms and cards.
 func NewFormatValidator() *Validator {
 	return &Validator{
-		Markups:        DefaultMarkups,
-		UnknownAtoms:   true,
-		UnknownCards:   true,
-		MarkupSections: DefaultMarkupSections,
-		ListSections:   DefaultListSections,
-		ImageSection:   DefaultImageSection,
+		Markups:           DefaultMarkups,
+		UnknownAtoms:      true,
+		UnknownCards:      true,
+		MarkupSections:    DefaultMarkupSections,
+		ListSections:      DefaultListSections,
+		SectionAttributes: DefaultSectionAttributes,
+		ImageSection:      DefaultImageSection,
 	}
 }
 
-// Validate will walk the specified mobiledoc and check if it is valid.
+// Validate will walk the specified mobiledoc and check if it is valid. The
+// first problem is returned as a *ValidationError.
 func (v *Validator) Validate(doc Document) error {
+	// validate document
+	errs := v.validate(doc)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// ValidateAll will walk the specified mobiledoc and check if it is valid. All
+// problems are returned as ValidationErrors.
+func (v *Validator) ValidateAll(doc Document) error {
+	// validate document
+	errs := v.validate(doc)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (v *Validator) validate(doc Document) ValidationErrors {
+	// prepare validation
+	val := validation{Validator: v, doc: doc}
+
 	// check version
-	if doc.Version != Version {
-		return fmt.Errorf("invalid version")
+	if doc.Version != Version031 && doc.Version != Version032 {
+		val.fail(ErrInvalidVersion, doc.Version, "version")
 	}
 
 	// validate markups
-	for _, markup := range doc.Markups {
-		err := v.validateMarkup(markup)
-		if err != nil {
-			return err
-		}
+	for i, markup := range doc.Markups {
+		val.validateMarkup(i, markup)
 	}
 
-	// validate markups
-	for _, atom := range doc.Atoms {
-		err := v.validateAtom(atom)
-		if err != nil {
-			return err
-		}
+	// validate atoms
+	for i, atom := range doc.Atoms {
+		val.validateAtom(i, atom)
 	}
 
 	// validate cards
-	for _, card := range doc.Cards {
-		err := v.validateCard(card)
-		if err != nil {
-			return err
-		}
+	for i, card := range doc.Cards {
+		val.validateCard(i, card)
 	}
 
 	// validate sections
-	for _, section := range doc.Sections {
-		err := v.validateSection(section)
-		if err != nil {
-			return err
-		}
+	for i, section := range doc.Sections {
+		val.validateSection(i, section)
 	}
 
-	return nil
+	return val.errors
+}
+
+type validation struct {
+	*Validator
+	doc    Document
+	errors ValidationErrors
+}
+
+func (v *validation) fail(kind error, name string, path string, args ...interface{}) {
+	// format path
+	if len(args) > 0 {
+		path = fmt.Sprintf(path, args...)
+	}
+
+	// add error
+	v.errors = append(v.errors, &ValidationError{
+		Path: path,
+		Name: name,
+		Kind: kind,
+	})
 }
 
-func (v *Validator) validateMarkup(markup Markup) error {
+func (v *validation) validateMarkup(index int, markup Markup) {
 	// check markup allowance
 	validator, ok := v.Markups[markup.Tag]
 	if !ok {
-		return fmt.Errorf("invalid markup tag")
+		v.fail(ErrInvalidMarkupTag, markup.Tag, "markups[%d]", index)
+		return
 	}
 
 	// return if validator is missing
 	if validator == nil {
-		return nil
+		return
 	}
 
 	// validate attributes
 	if !validator(markup.Attributes) {
-		return fmt.Errorf("invalid markup attributes")
+		v.fail(ErrInvalidMarkupAttributes, markup.Tag, "markups[%d]", index)
 	}
-
-	return nil
 }
 
-func (v *Validator) validateAtom(atom Atom) error {
+func (v *validation) validateAtom(index int, atom Atom) {
 	// check atom existence
 	validator, ok := v.Atoms[atom.Name]
 	if !ok && !v.UnknownAtoms {
-		return fmt.Errorf("invalid atom name")
+		v.fail(ErrInvalidAtomName, atom.Name, "atoms[%d]", index)
+		return
 	}
 
 	// check validator
 	if validator == nil {
-		return nil
+		return
 	}
 
 	// validate atom
 	if !validator(atom.Text, atom.Payload) {
-		return fmt.Errorf("invalid atom text or payload")
+		v.fail(ErrInvalidAtom, atom.Name, "atoms[%d]", index)
 	}
-
-	return nil
 }
 
-func (v *Validator) validateCard(card Card) error {
+func (v *validation) validateCard(index int, card Card) {
 	// check card existence
 	validator, ok := v.Cards[card.Name]
 	if !ok && !v.UnknownCards {
-		return fmt.Errorf("invalid card name")
+		v.fail(ErrInvalidCardName, card.Name, "cards[%d]", index)
+		return
 	}
 
 	// check validator
 	if validator == nil {
-		return nil
+		return
 	}
 
 	// validate card
 	if !validator(card.Payload) {
-		return fmt.Errorf("invalid card payload")
+		v.fail(ErrInvalidCardPayload, card.Name, "cards[%d]", index)
 	}
-
-	return nil
 }
 
-func (v *Validator) validateSection(section Section) error {
+func (v *validation) validateSection(index int, section Section) {
 	// run validators based on type
 	switch section.Type {
 	case MarkupSection:
-		return v.validateMarkupSection(section)
+		v.validateMarkupSection(index, section)
 	case ImageSection:
-		return v.validateImageSection(section)
+		v.validateImageSection(index, section)
 	case ListSection:
-		return v.validateListSection(section)
+		v.validateListSection(index, section)
+	case CardSection:
+		v.validateCardSection(index, section)
+	default:
+		v.fail(ErrInvalidSectionType, section.Tag, "sections[%d]", index)
 	}
-
-	return nil
 }
 
-func (v *Validator) validateMarkupSection(section Section) error {
+func (v *validation) validateMarkupSection(index int, section Section) {
 	// validate tag
 	if !contains(v.MarkupSections, section.Tag) {
-		return fmt.Errorf("invalid markup section tag")
+		v.fail(ErrInvalidMarkupSectionTag, section.Tag, "sections[%d]", index)
 	}
 
-	return nil
+	// validate attributes
+	v.validateSectionAttributes(index, section)
+
+	// validate markers
+	openMarkups := 0
+	for i, marker := range section.Markers {
+		openMarkups = v.validateMarker(openMarkups, marker, "sections[%d].markers[%d]", index, i)
+	}
 }
 
-func (v *Validator) validateImageSection(image Section) error {
+func (v *validation) validateImageSection(index int, image Section) {
 	// check availability
 	if v.ImageSection == nil {
-		return fmt.Errorf("invalid image section")
+		v.fail(ErrInvalidImageSection, image.Source, "sections[%d]", index)
+		return
 	}
 
 	// check src
 	if !v.ImageSection(image.Source) {
-		return fmt.Errorf("invalid image section src")
+		v.fail(ErrInvalidImageSectionSource, image.Source, "sections[%d]", index)
 	}
-
-	return nil
 }
 
-func (v *Validator) validateListSection(list Section) error {
+func (v *validation) validateListSection(index int, list Section) {
 	// validate tag
 	if !contains(v.ListSections, list.Tag) {
-		return fmt.Errorf("invalid list section tag")
+		v.fail(ErrInvalidListSectionTag, list.Tag, "sections[%d]", index)
 	}
 
-	return nil
+	// validate attributes
+	v.validateSectionAttributes(index, list)
+
+	// validate items
+	for i, item := range list.Items {
+		openMarkups := 0
+		for j, marker := range item {
+			openMarkups = v.validateMarker(openMarkups, marker, "sections[%d].items[%d][%d]", index, i, j)
+		}
+	}
+}
+
+func (v *validation) validateCardSection(index int, section Section) {
+	// check card reference
+	if !v.hasCard(section.Card) {
+		name := ""
+		if section.Card != nil {
+			name = section.Card.Name
+		}
+		v.fail(ErrInvalidCardReference, name, "sections[%d]", index)
+	}
+}
+
+func (v *validation) validateSectionAttributes(index int, section Section) {
+	// return if attributes are empty
+	if len(section.Attributes) == 0 {
+		return
+	}
+
+	// check version
+	if v.doc.Version != Version032 {
+		v.fail(ErrInvalidSectionAttributes, section.Tag, "sections[%d]", index)
+		return
+	}
+
+	// check tag allowance
+	validator, ok := v.SectionAttributes[section.Tag]
+	if !ok {
+		v.fail(ErrInvalidSectionAttributes, section.Tag, "sections[%d]", index)
+		return
+	}
+
+	// return if validator is missing
+	if validator == nil {
+		return
+	}
+
+	// validate attributes
+	if !validator(section.Attributes) {
+		v.fail(ErrInvalidSectionAttributes, section.Tag, "sections[%d]", index)
+	}
+}
+
+func (v *validation) validateMarker(openMarkups int, marker Marker, path string, args ...interface{}) int {
+	// check markup references
+	for _, markup := range marker.OpenMarkups {
+		if !v.hasMarkup(markup) {
+			name := ""
+			if markup != nil {
+				name = markup.Tag
+			}
+			v.fail(ErrInvalidMarkupReference, name, path, args...)
+		}
+	}
+
+	// check type
+	switch marker.Type {
+	case TextMarker:
+	case AtomMarker:
+		// check atom reference
+		if !v.hasAtom(marker.Atom) {
+			name := ""
+			if marker.Atom != nil {
+				name = marker.Atom.Name
+			}
+			v.fail(ErrInvalidAtomReference, name, path, args...)
+		}
+	default:
+		v.fail(ErrInvalidMarkerType, "", path, args...)
+	}
+
+	// check closed markups
+	openMarkups += len(marker.OpenMarkups) - marker.ClosedMarkups
+	if marker.ClosedMarkups < 0 || openMarkups < 0 {
+		v.fail(ErrInvalidClosedMarkups, "", path, args...)
+		return 0
+	}
+
+	return openMarkups
+}
+
+func (v *validation) hasMarkup(markup *Markup) bool {
+	for i := range v.doc.Markups {
+		if &v.doc.Markups[i] == markup {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validation) hasAtom(atom *Atom) bool {
+	for i := range v.doc.Atoms {
+		if &v.doc.Atoms[i] == atom {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validation) hasCard(card *Card) bool {
+	for i := range v.doc.Cards {
+		if &v.doc.Cards[i] == card {
+			return true
+		}
+	}
+	return false
 }
@@ -1,6 +1,7 @@
 package mobiledoc
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -60,6 +61,7 @@ func TestValidatorInvalidVersion(t *testing.T) {
 		Version: "foo",
 	})
 	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidVersion))
 }
 
 func TestValidatorInvalidMarkup(t *testing.T) {
@@ -203,3 +205,148 @@ func TestValidatorInvalidListSection(t *testing.T) {
 	})
 	assert.Error(t, err)
 }
+
+func TestValidatorSectionAttributes(t *testing.T) {
+	v := NewDefaultValidator()
+
+	err := v.Validate(Document{
+		Version: Version032,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "h1", Attributes: Map{"data-md-text-align": "center"}},
+			{Type: ListSection, Tag: "ol", Attributes: Map{"data-md-text-align": "justify"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	err = v.Validate(Document{
+		Version: Version,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Attributes: Map{"data-md-text-align": "center"}},
+		},
+	})
+	assert.Error(t, err)
+
+	err = v.Validate(Document{
+		Version: Version032,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Attributes: Map{"data-md-text-align": "middle"}},
+		},
+	})
+	assert.Error(t, err)
+
+	err = v.Validate(Document{
+		Version: Version032,
+		Sections: []Section{
+			{Type: ListSection, Tag: "ul", Attributes: Map{"class": "foo"}},
+		},
+	})
+	assert.Error(t, err)
+
+	v = NewEmptyValidator()
+	v.MarkupSections = []string{"p"}
+
+	err = v.Validate(Document{
+		Version: Version032,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Attributes: Map{"data-md-text-align": "center"}},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestValidatorErrors(t *testing.T) {
+	v := NewDefaultValidator()
+
+	doc := Document{
+		Version: Version,
+		Markups: []Markup{
+			{Tag: "b"},
+			{Tag: "z"},
+		},
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p"},
+			{Type: MarkupSection, Tag: "y"},
+		},
+	}
+	doc.Sections = append(doc.Sections, Section{
+		Type: MarkupSection,
+		Tag:  "p",
+		Markers: []Marker{
+			{Type: TextMarker, OpenMarkups: []*Markup{&doc.Markups[0]}, Text: "foo"},
+			{Type: TextMarker, OpenMarkups: []*Markup{{Tag: "i"}}, ClosedMarkups: 3, Text: "bar"},
+		},
+	}, Section{
+		Type: ListSection,
+		Tag:  "ul",
+		Items: [][]Marker{
+			{},
+			{{Type: AtomMarker, Atom: &Atom{Name: "foo"}}},
+		},
+	}, Section{
+		Type: CardSection,
+		Card: &Card{Name: "bar"},
+	})
+
+	err := v.Validate(doc)
+	assert.Equal(t, &ValidationError{
+		Path: "markups[1]",
+		Name: "z",
+		Kind: ErrInvalidMarkupTag,
+	}, err)
+	assert.True(t, errors.Is(err, ErrInvalidMarkupTag))
+	assert.Equal(t, `markups[1]: invalid markup tag "z"`, err.Error())
+
+	err = v.ValidateAll(doc)
+	assert.Equal(t, ValidationErrors{
+		{Path: "markups[1]", Name: "z", Kind: ErrInvalidMarkupTag},
+		{Path: "sections[1]", Name: "y", Kind: ErrInvalidMarkupSectionTag},
+		{Path: "sections[2].markers[1]", Name: "i", Kind: ErrInvalidMarkupReference},
+		{Path: "sections[2].markers[1]", Kind: ErrInvalidClosedMarkups},
+		{Path: "sections[3].items[1][0]", Name: "foo", Kind: ErrInvalidAtomReference},
+		{Path: "sections[4]", Name: "bar", Kind: ErrInvalidCardReference},
+	}, err)
+	assert.True(t, errors.Is(err, ErrInvalidMarkupSectionTag))
+	assert.True(t, errors.Is(err, ErrInvalidCardReference))
+	assert.False(t, errors.Is(err, ErrInvalidVersion))
+	assert.Equal(t, `markups[1]: invalid markup tag "z"; sections[1]: invalid markup section tag "y"; `+
+		`sections[2].markers[1]: invalid markup reference "i"; sections[2].markers[1]: invalid closed markups; `+
+		`sections[3].items[1][0]: invalid atom reference "foo"; sections[4]: invalid card reference "bar"`, err.Error())
+
+	var list ValidationErrors
+	assert.True(t, errors.As(err, &list))
+	assert.Len(t, list, 6)
+
+	err = v.ValidateAll(sampleDoc())
+	assert.Error(t, err)
+
+	err = formatValidator.ValidateAll(sampleDoc())
+	assert.NoError(t, err)
+}
+
+func TestValidatorInvalidMarker(t *testing.T) {
+	v := NewDefaultValidator()
+
+	err := v.Validate(Document{
+		Version: Version,
+		Sections: []Section{
+			{Type: MarkupSection, Tag: "p", Markers: []Marker{
+				{Type: 3},
+			}},
+		},
+	})
+	assert.Equal(t, &ValidationError{
+		Path: "sections[0].markers[0]",
+		Kind: ErrInvalidMarkerType,
+	}, err)
+
+	err = v.Validate(Document{
+		Version: Version,
+		Sections: []Section{
+			{Type: 7},
+		},
+	})
+	assert.Equal(t, &ValidationError{
+		Path: "sections[0]",
+		Kind: ErrInvalidSectionType,
+	}, err)
+}
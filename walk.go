@@ -0,0 +1,235 @@
+package mobiledoc
+
+import "errors"
+
+// SkipChildren may be returned by the EnterSection, EnterItem and OpenMarkup
+// callbacks to skip the content of the visited node. The matching LeaveSection,
+// LeaveItem or CloseMarkup callback is skipped as well.
+var SkipChildren = errors.New("skip children")
+
+// SkipAll may be returned by any callback to stop walking without an error.
+var SkipAll = errors.New("skip all")
+
+// Location identifies the visited node.
+type Location struct {
+	// The section index.
+	Section int
+
+	// The item index or -1 if not in a list item.
+	Item int
+
+	// The marker index or -1 if not at a marker.
+	Marker int
+}
+
+// Visitor defines the callbacks used when walking a document. All callbacks
+// are optional.
+type Visitor struct {
+	// Called for every section.
+	EnterSection func(loc Location, section *Section) error
+	LeaveSection func(loc Location, section *Section) error
+
+	// Called for every list item.
+	EnterItem func(loc Location, item []Marker) error
+	LeaveItem func(loc Location, item []Marker) error
+
+	// Called for every markup opened or closed by a marker. Markups that are
+	// left open by the last marker are closed after it.
+	OpenMarkup  func(loc Location, markup *Markup) error
+	CloseMarkup func(loc Location, markup *Markup) error
+
+	// Called for every text or atom marker.
+	Text func(loc Location, text string) error
+	Atom func(loc Location, atom *Atom) error
+
+	// Called for every card section.
+	Card func(loc Location, card *Card) error
+}
+
+// Walk will walk the document and call the visitor callbacks in document order.
+// The error returned by a callback is returned unless it is SkipChildren or
+// SkipAll.
+func Walk(doc Document, v Visitor) error {
+	err := v.walk(doc)
+	if err == SkipAll {
+		return nil
+	}
+
+	return err
+}
+
+func (v *Visitor) walk(doc Document) error {
+	// walk sections
+	for i := range doc.Sections {
+		err := v.walkSection(i, &doc.Sections[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Visitor) walkSection(index int, section *Section) error {
+	// get location
+	loc := Location{Section: index, Item: -1, Marker: -1}
+
+	// enter section
+	if v.EnterSection != nil {
+		err := v.EnterSection(loc, section)
+		if err == SkipChildren {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// walk content
+	switch section.Type {
+	case MarkupSection:
+		err := v.walkMarkers(loc, section.Markers)
+		if err != nil {
+			return err
+		}
+	case ListSection:
+		for i, item := range section.Items {
+			err := v.walkItem(Location{Section: index, Item: i, Marker: -1}, item)
+			if err != nil {
+				return err
+			}
+		}
+	case CardSection:
+		if v.Card != nil {
+			err := skipped(v.Card(loc, section.Card))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// leave section
+	if v.LeaveSection != nil {
+		err := skipped(v.LeaveSection(loc, section))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Visitor) walkItem(loc Location, item []Marker) error {
+	// enter item
+	if v.EnterItem != nil {
+		err := v.EnterItem(loc, item)
+		if err == SkipChildren {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// walk markers
+	err := v.walkMarkers(loc, item)
+	if err != nil {
+		return err
+	}
+
+	// leave item
+	if v.LeaveItem != nil {
+		err := skipped(v.LeaveItem(loc, item))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Visitor) walkMarkers(loc Location, markers []Marker) error {
+	// prepare stack and the depth from which on content is skipped
+	var stack []*Markup
+	skip := -1
+
+	for i, marker := range markers {
+		// set location
+		loc.Marker = i
+
+		// open markups
+		for _, markup := range marker.OpenMarkups {
+			stack = append(stack, markup)
+			if skip >= 0 || v.OpenMarkup == nil {
+				continue
+			}
+			err := v.OpenMarkup(loc, markup)
+			if err == SkipChildren {
+				skip = len(stack) - 1
+			} else if err != nil {
+				return err
+			}
+		}
+
+		// handle marker
+		if skip < 0 {
+			var err error
+			switch marker.Type {
+			case TextMarker:
+				if v.Text != nil {
+					err = v.Text(loc, marker.Text)
+				}
+			case AtomMarker:
+				if v.Atom != nil {
+					err = v.Atom(loc, marker.Atom)
+				}
+			}
+			err = skipped(err)
+			if err != nil {
+				return err
+			}
+		}
+
+		// close markups, remaining markups are closed after the last marker
+		closed := marker.ClosedMarkups
+		if i == len(markers)-1 {
+			closed = len(stack)
+		}
+		for j := 0; j < closed && len(stack) > 0; j++ {
+			err := v.closeMarkup(loc, &stack, &skip)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *Visitor) closeMarkup(loc Location, stack *[]*Markup, skip *int) error {
+	// pop markup
+	markup := (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+
+	// handle skipped markups
+	if *skip >= 0 {
+		if len(*stack) == *skip {
+			*skip = -1
+		}
+		return nil
+	}
+
+	// close markup
+	if v.CloseMarkup != nil {
+		return skipped(v.CloseMarkup(loc, markup))
+	}
+
+	return nil
+}
+
+func skipped(err error) error {
+	// ignore skip children
+	if err == SkipChildren {
+		return nil
+	}
+
+	return err
+}
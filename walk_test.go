@@ -0,0 +1,214 @@
+package mobiledoc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recordVisitor(events *[]string, skip string, ret error) Visitor {
+	record := func(event string) error {
+		*events = append(*events, event)
+		if event == skip {
+			return ret
+		}
+		return nil
+	}
+
+	return Visitor{
+		EnterSection: func(loc Location, section *Section) error {
+			return record(fmt.Sprintf("enter section %d", loc.Section))
+		},
+		LeaveSection: func(loc Location, section *Section) error {
+			return record(fmt.Sprintf("leave section %d", loc.Section))
+		},
+		EnterItem: func(loc Location, item []Marker) error {
+			return record(fmt.Sprintf("enter item %d.%d", loc.Section, loc.Item))
+		},
+		LeaveItem: func(loc Location, item []Marker) error {
+			return record(fmt.Sprintf("leave item %d.%d", loc.Section, loc.Item))
+		},
+		OpenMarkup: func(loc Location, markup *Markup) error {
+			return record(fmt.Sprintf("open %s", markup.Tag))
+		},
+		CloseMarkup: func(loc Location, markup *Markup) error {
+			return record(fmt.Sprintf("close %s", markup.Tag))
+		},
+		Text: func(loc Location, text string) error {
+			return record(fmt.Sprintf("text %q", text))
+		},
+		Atom: func(loc Location, atom *Atom) error {
+			return record(fmt.Sprintf("atom %s", atom.Name))
+		},
+		Card: func(loc Location, card *Card) error {
+			return record(fmt.Sprintf("card %s", card.Name))
+		},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	var events []string
+	err := Walk(sampleDoc(), recordVisitor(&events, "", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"enter section 0",
+		"card card1",
+		"leave section 0",
+		"enter section 1",
+		`text "foo"`,
+		"open b",
+		`text "foo"`,
+		"close b",
+		"open i",
+		`text "foo"`,
+		`text "foo"`,
+		"close i",
+		"open i",
+		"open a",
+		`text "foo"`,
+		"close a",
+		`text "foo"`,
+		"close i",
+		"leave section 1",
+		"enter section 2",
+		"atom atom1",
+		"open b",
+		"atom atom2",
+		"atom atom1",
+		"close b",
+		"leave section 2",
+		"enter section 3",
+		"leave section 3",
+		"enter section 4",
+		"enter item 4.0",
+		`text "foo"`,
+		"open b",
+		`text "foo"`,
+		"close b",
+		"leave item 4.0",
+		"enter item 4.1",
+		"open b",
+		`text "foo"`,
+		`text "<foo>"`,
+		"close b",
+		"leave item 4.1",
+		"leave section 4",
+		"enter section 5",
+		"enter item 5.0",
+		`text "bar"`,
+		"open i",
+		`text "bar"`,
+		"close i",
+		"leave item 5.0",
+		"enter item 5.1",
+		"open i",
+		`text "bar"`,
+		`text "<bar>"`,
+		"close i",
+		"leave item 5.1",
+		"leave section 5",
+		"enter section 6",
+		"card card2",
+		"leave section 6",
+	}, events)
+}
+
+func TestWalkLocation(t *testing.T) {
+	var locations []Location
+	err := Walk(sampleDoc(), Visitor{
+		Text: func(loc Location, text string) error {
+			locations = append(locations, loc)
+			return nil
+		},
+		Atom: func(loc Location, atom *Atom) error {
+			locations = append(locations, loc)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Location{
+		{Section: 1, Item: -1, Marker: 0},
+		{Section: 1, Item: -1, Marker: 1},
+		{Section: 1, Item: -1, Marker: 2},
+		{Section: 1, Item: -1, Marker: 3},
+		{Section: 1, Item: -1, Marker: 4},
+		{Section: 1, Item: -1, Marker: 5},
+		{Section: 2, Item: -1, Marker: 0},
+		{Section: 2, Item: -1, Marker: 1},
+		{Section: 2, Item: -1, Marker: 2},
+		{Section: 4, Item: 0, Marker: 0},
+		{Section: 4, Item: 0, Marker: 1},
+		{Section: 4, Item: 1, Marker: 0},
+		{Section: 4, Item: 1, Marker: 1},
+		{Section: 5, Item: 0, Marker: 0},
+		{Section: 5, Item: 0, Marker: 1},
+		{Section: 5, Item: 1, Marker: 0},
+		{Section: 5, Item: 1, Marker: 1},
+	}, locations)
+}
+
+func TestWalkSkipSection(t *testing.T) {
+	var events []string
+	err := Walk(sampleDoc(), recordVisitor(&events, "enter section 1", SkipChildren))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"enter section 0",
+		"card card1",
+		"leave section 0",
+		"enter section 1",
+		"enter section 2",
+		"atom atom1",
+	}, events[:6])
+}
+
+func TestWalkSkipItem(t *testing.T) {
+	var events []string
+	err := Walk(sampleDoc(), recordVisitor(&events, "enter item 4.0", SkipChildren))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"enter section 4",
+		"enter item 4.0",
+		"enter item 4.1",
+		"open b",
+		`text "foo"`,
+	}, events[28:33])
+}
+
+func TestWalkSkipMarkup(t *testing.T) {
+	var events []string
+	err := Walk(sampleDoc(), recordVisitor(&events, "open b", SkipChildren))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"enter section 1",
+		`text "foo"`,
+		"open b",
+		"open i",
+		`text "foo"`,
+	}, events[3:8])
+}
+
+func TestWalkSkipAll(t *testing.T) {
+	var events []string
+	err := Walk(sampleDoc(), recordVisitor(&events, `text "bar"`, SkipAll))
+	assert.NoError(t, err)
+	assert.Len(t, events, 45)
+	assert.Equal(t, []string{
+		"enter section 5",
+		"enter item 5.0",
+		`text "bar"`,
+	}, events[42:])
+}
+
+func TestWalkError(t *testing.T) {
+	fail := errors.New("fail")
+
+	var events []string
+	err := Walk(sampleDoc(), recordVisitor(&events, "card card1", fail))
+	assert.Equal(t, fail, err)
+	assert.Equal(t, []string{
+		"enter section 0",
+		"card card1",
+	}, events)
+}